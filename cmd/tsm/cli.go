@@ -0,0 +1,577 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/wake/tmux-session-menu/internal/config"
+	"github.com/wake/tmux-session-menu/internal/hooks"
+	"github.com/wake/tmux-session-menu/internal/store"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+	"github.com/wake/tmux-session-menu/internal/ui"
+)
+
+// subcommand 是一個供腳本使用的 CLI 子命令。
+type subcommand struct {
+	usage string
+	run   func(cfg config.Config, fs *flag.FlagSet, args []string) error
+}
+
+// subcommands 列出所有子命令，未符合任何子命令時啟動 TUI。
+var subcommands = map[string]subcommand{
+	"list":     {"list [--json]", runList},
+	"new":      {"new <name> [-c dir] [--template name]", runNew},
+	"kill":     {"kill <name>", runKill},
+	"rename":   {"rename <old> <new>", runRename},
+	"attach":   {"attach <name>", runAttach},
+	"status":   {"status [name] [--json]", runStatus},
+	"hooks":    {"hooks <install|uninstall|status> [--project]", runHooks},
+	"hook":     {"hook <event>", runHook},
+	"stats":    {"stats [--json]", runStats},
+	"snapshot": {"snapshot <save|restore> [--resume]", runSnapshot},
+}
+
+// sessionJSON 是 list / status 的 JSON 輸出格式。
+type sessionJSON struct {
+	Name     string       `json:"name"`
+	ID       string       `json:"id"`
+	Path     string       `json:"path"`
+	Attached bool         `json:"attached"`
+	Status   string       `json:"status"`
+	Reason   string       `json:"reason,omitempty"`
+	AIModel  string       `json:"ai_model"`
+	Agent    string       `json:"agent"`
+	Group    string       `json:"group"`
+	Activity time.Time    `json:"activity"`
+	Windows  []windowJSON `json:"windows"`
+}
+
+type windowJSON struct {
+	Index  int        `json:"index"`
+	Name   string     `json:"name"`
+	Status string     `json:"status"`
+	Panes  []paneJSON `json:"panes"`
+}
+
+type paneJSON struct {
+	ID      string `json:"id"`
+	Index   int    `json:"index"`
+	Command string `json:"command"`
+	PID     int    `json:"pid"`
+	Active  bool   `json:"active"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Tool    string `json:"tool,omitempty"`
+	Target  string `json:"target,omitempty"`
+	Agent   string `json:"agent"`
+	AIModel string `json:"ai_model"`
+}
+
+func toJSON(s tmux.Session) sessionJSON {
+	windows := make([]windowJSON, 0, len(s.Windows))
+	for _, w := range s.Windows {
+		panes := make([]paneJSON, 0, len(w.Panes))
+		for _, p := range w.Panes {
+			panes = append(panes, paneJSON{
+				ID:      p.ID,
+				Index:   p.Index,
+				Command: p.Command,
+				PID:     p.PID,
+				Active:  p.Active,
+				Status:  p.Status.String(),
+				Reason:  string(p.Wait.Reason),
+				Tool:    p.Wait.Tool,
+				Target:  p.Wait.Command,
+				Agent:   p.Agent,
+				AIModel: p.AIModel,
+			})
+		}
+		windows = append(windows, windowJSON{Index: w.Index, Name: w.Name, Status: w.Status.String(), Panes: panes})
+	}
+	return sessionJSON{
+		Name:     s.Name,
+		ID:       s.ID,
+		Path:     s.Path,
+		Attached: s.Attached,
+		Status:   s.Status.String(),
+		Reason:   string(s.Wait.Reason),
+		AIModel:  s.AIModel,
+		Agent:    s.Agent,
+		Group:    s.GroupName,
+		Activity: s.Activity,
+		Windows:  windows,
+	}
+}
+
+// openStore 開啟資料目錄下的 SQLite store，必要時建立目錄。
+func openStore(cfg config.Config) (*store.Store, error) {
+	dataDir := config.ExpandPath(cfg.DataDir)
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+	st, err := store.Open(filepath.Join(dataDir, "state.db"))
+	if err != nil {
+		return nil, fmt.Errorf("open store: %w", err)
+	}
+	return st, nil
+}
+
+// loadSessions 載入與選單相同的 session 資料（狀態、AI 模型、群組）。
+func loadSessions(cfg config.Config) ([]tmux.Session, error) {
+	st, err := openStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+
+	msg := ui.LoadSessions(ui.Deps{
+		TmuxMgr: newManager(),
+		Store:   st,
+		Cfg:     cfg,
+	})
+	return msg.Sessions, msg.Err
+}
+
+// newManager 建立以子程序執行 tmux 的 Manager。
+func newManager() *tmux.Manager {
+	return tmux.NewManager(tmux.NewRealExecutor())
+}
+
+// runSubcommand 執行子命令；name 不是子命令時回傳 false。
+func runSubcommand(cfg config.Config, name string, args []string) (bool, error) {
+	cmd, ok := subcommands[name]
+	if !ok {
+		return false, nil
+	}
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: tsm %s\n", cmd.usage)
+		fs.PrintDefaults()
+	}
+	return true, cmd.run(cfg, fs, args)
+}
+
+// parseArgs 解析旗標，旗標可出現在位置參數前後（例如 new foo -c /tmp）；
+// flag 套件遇到第一個位置參數就停止解析。"--" 之後的參數都視為位置參數。
+// 解析後 fs.Args() 只包含位置參數。
+func parseArgs(fs *flag.FlagSet, args []string) {
+	var positional []string
+	for {
+		fs.Parse(args)
+		rest := fs.Args()
+		if consumed := args[:len(args)-len(rest)]; len(consumed) > 0 && consumed[len(consumed)-1] == "--" {
+			positional = append(positional, rest...)
+			break
+		}
+		if len(rest) == 0 {
+			break
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+	fs.Parse(append([]string{"--"}, positional...))
+}
+
+// requireArgs 檢查位置參數數量。
+func requireArgs(fs *flag.FlagSet, n int) error {
+	if fs.NArg() != n {
+		fs.Usage()
+		return fmt.Errorf("%s: expected %d argument(s), got %d", fs.Name(), n, fs.NArg())
+	}
+	return nil
+}
+
+func runList(cfg config.Config, fs *flag.FlagSet, args []string) error {
+	asJSON := fs.Bool("json", false, "以 JSON 輸出")
+	parseArgs(fs, args)
+	if err := requireArgs(fs, 0); err != nil {
+		return err
+	}
+
+	sessions, err := loadSessions(cfg)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		out := make([]sessionJSON, 0, len(sessions))
+		for _, s := range sessions {
+			out = append(out, toJSON(s))
+		}
+		return writeJSON(os.Stdout, out)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATUS\tACTIVITY\tMODEL\tGROUP")
+	for _, s := range sessions {
+		fmt.Fprintf(w, "%s\t%s %s\t%s\t%s\t%s\n",
+			s.Name, s.StatusIcon(), s.Status, s.RelativeTime(), s.AIModel, s.GroupName)
+	}
+	return w.Flush()
+}
+
+func runNew(cfg config.Config, fs *flag.FlagSet, args []string) error {
+	dir := fs.String("c", "", "工作目錄（預設為目前目錄）")
+	template := fs.String("template", "", "以設定檔中的 [[template]] 建立 window 與 pane")
+	parseArgs(fs, args)
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+	if err := tmux.ValidateSessionName(fs.Arg(0)); err != nil {
+		return err
+	}
+
+	path := *dir
+	if path == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		path = wd
+	}
+	path = config.ExpandPath(path)
+	if *template == "" {
+		return newManager().NewSession(fs.Arg(0), path)
+	}
+	tpl, ok := cfg.FindTemplate(*template)
+	if !ok {
+		return fmt.Errorf("unknown template %q", *template)
+	}
+	return newManager().NewSessionFromTemplate(fs.Arg(0), path, tpl)
+}
+
+func runKill(cfg config.Config, fs *flag.FlagSet, args []string) error {
+	parseArgs(fs, args)
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+	return newManager().KillSession(fs.Arg(0))
+}
+
+func runRename(cfg config.Config, fs *flag.FlagSet, args []string) error {
+	parseArgs(fs, args)
+	if err := requireArgs(fs, 2); err != nil {
+		return err
+	}
+	return newManager().RenameSession(fs.Arg(0), fs.Arg(1))
+}
+
+func runAttach(cfg config.Config, fs *flag.FlagSet, args []string) error {
+	parseArgs(fs, args)
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+	return newManager().Attach(fs.Arg(0))
+}
+
+func runStatus(cfg config.Config, fs *flag.FlagSet, args []string) error {
+	asJSON := fs.Bool("json", false, "以 JSON 輸出")
+	parseArgs(fs, args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("status: expected at most 1 argument, got %d", fs.NArg())
+	}
+
+	name := fs.Arg(0)
+	if name == "" {
+		pane := os.Getenv("TMUX_PANE")
+		if pane == "" {
+			return errors.New("status: session name required outside tmux")
+		}
+		current, err := newManager().PaneSession(pane)
+		if err != nil {
+			return fmt.Errorf("status: resolve current session: %w", err)
+		}
+		name = current
+	}
+
+	sessions, err := loadSessions(cfg)
+	if err != nil {
+		return err
+	}
+	for _, s := range sessions {
+		if s.Name != name {
+			continue
+		}
+		if *asJSON {
+			return writeJSON(os.Stdout, toJSON(s))
+		}
+		fmt.Println(s.Status)
+		return nil
+	}
+	return fmt.Errorf("status: session %q not found", name)
+}
+
+func runHooks(cfg config.Config, fs *flag.FlagSet, args []string) error {
+	project := fs.Bool("project", false, "使用目前目錄的 .claude/settings.json（預設為使用者層級）")
+	if len(args) == 0 {
+		fs.Usage()
+		return errors.New("hooks: action required")
+	}
+	action := args[0]
+	parseArgs(fs, args[1:])
+	if err := requireArgs(fs, 0); err != nil {
+		return err
+	}
+
+	path, err := hooks.UserSettingsPath()
+	if *project {
+		var wd string
+		wd, err = os.Getwd()
+		path = hooks.ProjectSettingsPath(wd)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "install":
+		self, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("locate executable: %w", err)
+		}
+		changed, err := hooks.Install(path, self)
+		if err != nil {
+			return err
+		}
+		if changed {
+			fmt.Printf("Installed tsm hooks into %s\n", path)
+		} else {
+			fmt.Printf("tsm hooks already installed in %s\n", path)
+		}
+	case "uninstall":
+		changed, err := hooks.Uninstall(path)
+		if err != nil {
+			return err
+		}
+		if changed {
+			fmt.Printf("Removed tsm hooks from %s\n", path)
+		} else {
+			fmt.Printf("No tsm hooks found in %s\n", path)
+		}
+	case "status":
+		settings, err := hooks.Load(path)
+		if err != nil {
+			return err
+		}
+		installed := hooks.Installed(settings)
+		fmt.Println(path)
+		for _, event := range hooks.Events {
+			mark := "✗"
+			if installed[event] {
+				mark = "✓"
+			}
+			fmt.Printf("  %s %s\n", mark, event)
+		}
+	default:
+		fs.Usage()
+		return fmt.Errorf("hooks: unknown action %q", action)
+	}
+	return nil
+}
+
+// hookPayload 是 Claude Code 透過 stdin 傳給 hook 的 JSON（只取用需要的欄位）。
+type hookPayload struct {
+	HookEventName    string        `json:"hook_event_name"`
+	ToolName         string        `json:"tool_name"`
+	ToolInput        hookToolInput `json:"tool_input"`
+	NotificationType string        `json:"notification_type"`
+	Message          string        `json:"message"`
+}
+
+// hookToolInput 是 tool_input 中可用來說明權限請求的欄位，依工具不同只會有其中之一。
+type hookToolInput struct {
+	Command  string `json:"command"`
+	FilePath string `json:"file_path"`
+	URL      string `json:"url"`
+	Pattern  string `json:"pattern"`
+}
+
+// target 回傳工具作用的對象（指令、檔案、網址或搜尋樣式）。
+func (in hookToolInput) target() string {
+	for _, s := range []string{in.Command, in.FilePath, in.URL, in.Pattern} {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// apply 以 payload 補充等待原因與請求的工具。
+func (p hookPayload) apply(hs *tmux.HookStatus) {
+	if hs.Status != tmux.StatusWaiting {
+		return
+	}
+	hs.Reason = tmux.HookEventWait(hs.Event, p.ToolName, p.NotificationType, p.Message)
+	if hs.Reason == tmux.ReasonPermission {
+		hs.Tool = p.ToolName
+		hs.Command = p.ToolInput.target()
+	}
+}
+
+// runHook 由 Claude Code hook 呼叫，將事件對應的狀態寫入目前 session 的狀態檔案。
+// 不在 tmux 內執行時直接忽略，避免干擾 Claude Code。
+func runHook(cfg config.Config, fs *flag.FlagSet, args []string) error {
+	parseArgs(fs, args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("hook: expected at most 1 argument, got %d", fs.NArg())
+	}
+
+	var payload hookPayload
+	if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice == 0 {
+		// payload 格式錯誤不影響狀態寫入，事件名稱以參數為準
+		json.NewDecoder(os.Stdin).Decode(&payload)
+	}
+	event := fs.Arg(0)
+	if event == "" {
+		event = payload.HookEventName
+	}
+	if event == "" {
+		fs.Usage()
+		return errors.New("hook: event required")
+	}
+
+	pane := os.Getenv("TMUX_PANE")
+	if pane == "" {
+		return nil
+	}
+	id, session, err := newManager().PaneSessionID(pane)
+	if err != nil {
+		return fmt.Errorf("hook: resolve session for pane %s: %w", pane, err)
+	}
+
+	hs := tmux.NewHookStatus(event)
+	hs.Pane = pane
+	payload.apply(&hs)
+	prev, prevErr := tmux.ReadHookStatus(cfg.StatusDir(), id)
+	if err := tmux.WriteHookStatus(cfg.StatusDir(), id, hs); err != nil {
+		return err
+	}
+	if prevErr == nil && prev.Pane == pane && prev.Status == hs.Status {
+		return nil
+	}
+	// 歷史紀錄只是輔助資訊，寫入失敗不應讓 Claude Code 顯示 hook 錯誤
+	recordHookEvent(cfg, session, pane, hs)
+	return nil
+}
+
+// recordHookEvent 將 hook 造成的狀態轉換寫入歷史紀錄。
+func recordHookEvent(cfg config.Config, session, pane string, hs tmux.HookStatus) {
+	st, err := openStore(cfg)
+	if err != nil {
+		return
+	}
+	defer st.Close()
+
+	old := tmux.StatusIdle.String()
+	if last, ok, err := st.LastStatusEvent(session, pane); err != nil {
+		return
+	} else if ok {
+		old = last.NewStatus
+	}
+	if old == hs.Status.String() {
+		return
+	}
+	st.RecordStatusEvents([]store.StatusEvent{{
+		SessionName: session,
+		PaneID:      pane,
+		OldStatus:   old,
+		NewStatus:   hs.Status.String(),
+		Source:      string(tmux.SourceHook),
+		At:          time.Unix(hs.Timestamp, 0),
+	}})
+}
+
+// statsJSON 是 stats 的 JSON 輸出格式，時間以秒為單位。
+type statsJSON struct {
+	Session string  `json:"session"`
+	Running float64 `json:"running_sec"`
+	Waiting float64 `json:"waiting_sec"`
+	Error   float64 `json:"error_sec"`
+}
+
+// runStats 列出今天各 session 處於執行中、等待與錯誤狀態的時間（各 pane 加總）。
+func runStats(cfg config.Config, fs *flag.FlagSet, args []string) error {
+	asJSON := fs.Bool("json", false, "以 JSON 輸出")
+	parseArgs(fs, args)
+	if err := requireArgs(fs, 0); err != nil {
+		return err
+	}
+
+	st, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	now := time.Now()
+	y, m, d := now.Date()
+	durations, err := st.StatusDurations(time.Date(y, m, d, 0, 0, 0, 0, now.Location()), now)
+	if err != nil {
+		return err
+	}
+
+	var stats []statsJSON
+	index := make(map[string]int)
+	for _, sd := range durations {
+		i, ok := index[sd.SessionName]
+		if !ok {
+			i = len(stats)
+			index[sd.SessionName] = i
+			stats = append(stats, statsJSON{Session: sd.SessionName})
+		}
+		switch sd.Status {
+		case tmux.StatusRunning.String():
+			stats[i].Running += sd.Duration.Seconds()
+		case tmux.StatusWaiting.String():
+			stats[i].Waiting += sd.Duration.Seconds()
+		case tmux.StatusError.String():
+			stats[i].Error += sd.Duration.Seconds()
+		}
+	}
+
+	if *asJSON {
+		if stats == nil {
+			stats = []statsJSON{}
+		}
+		return writeJSON(os.Stdout, stats)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SESSION\tRUNNING\tWAITING\tERROR")
+	for _, s := range stats {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Session, formatSeconds(s.Running), formatSeconds(s.Waiting), formatSeconds(s.Error))
+	}
+	return w.Flush()
+}
+
+// formatSeconds 將秒數格式化為 1h02m、5m30s 這類較短的字串。
+func formatSeconds(sec float64) string {
+	return time.Duration(sec * float64(time.Second)).Round(time.Second).String()
+}
+
+// writeJSON 以縮排格式輸出 JSON。
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printUsage 輸出主程式與所有子命令的用法。
+func printUsage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "Usage: tsm [--popup | --inline]")
+	fmt.Fprintln(out, "       tsm <command> [args]")
+	fmt.Fprintln(out, "\nCommands:")
+	for _, name := range []string{"list", "new", "kill", "rename", "attach", "status", "stats", "snapshot", "hooks", "hook"} {
+		fmt.Fprintf(out, "  %s\n", subcommands[name].usage)
+	}
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wake/tmux-session-menu/internal/config"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		dir  string
+		want []string
+	}{
+		{"flags first", []string{"-c", "/tmp", "foo"}, "/tmp", []string{"foo"}},
+		{"flags last", []string{"foo", "-c", "/tmp"}, "/tmp", []string{"foo"}},
+		{"flags between", []string{"old", "-c", "/tmp", "new"}, "/tmp", []string{"old", "new"}},
+		{"double dash", []string{"foo", "--", "-c", "/tmp"}, "", []string{"foo", "-c", "/tmp"}},
+		{"no args", nil, "", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			dir := fs.String("c", "", "")
+			parseArgs(fs, tt.args)
+			assert.Equal(t, tt.dir, *dir)
+			assert.Equal(t, tt.want, fs.Args())
+		})
+	}
+}
+
+// startTestTmux 讓 tmux 使用獨立的 socket 目錄，測試結束時關閉 server。
+func startTestTmux(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not installed")
+	}
+	t.Setenv("TMUX_TMPDIR", t.TempDir())
+	t.Setenv("TMUX", "")
+	t.Setenv("TMUX_PANE", "")
+	t.Cleanup(func() { exec.Command("tmux", "kill-server").Run() })
+}
+
+// captureStdout 執行 fn 並回傳其寫到 os.Stdout 的內容。
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	err = fn()
+	w.Close()
+	out, readErr := io.ReadAll(r)
+	require.NoError(t, readErr)
+	return string(out), err
+}
+
+// TestSubcommands_DocumentedOrder 依 usage 的參數順序（名稱在旗標之前）執行子命令。
+func TestSubcommands_DocumentedOrder(t *testing.T) {
+	startTestTmux(t)
+	cfg := config.Default()
+	cfg.DataDir = t.TempDir()
+	dir := t.TempDir()
+
+	run := func(args ...string) (string, error) {
+		return captureStdout(t, func() error {
+			ok, err := runSubcommand(cfg, args[0], args[1:])
+			require.True(t, ok)
+			return err
+		})
+	}
+
+	_, err := run("new", "api-v2", "-c", dir)
+	require.NoError(t, err)
+	sessions, err := exec.Command("tmux", "list-sessions", "-F", "#{session_name} #{session_path}").Output()
+	require.NoError(t, err)
+	assert.Equal(t, "api-v2 "+dir, strings.TrimSpace(string(sessions)))
+
+	out, err := run("status", "api-v2", "--json")
+	require.NoError(t, err)
+	assert.Contains(t, out, `"name": "api-v2"`)
+
+	// 名稱不完全相符時不應以前綴比對到 api-v2
+	_, err = run("kill", "api")
+	assert.Error(t, err)
+	_, err = run("rename", "api-v2", "api")
+	require.NoError(t, err)
+	_, err = run("kill", "api")
+	require.NoError(t, err)
+}
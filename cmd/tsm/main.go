@@ -1,19 +1,125 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/wake/tmux-session-menu/internal/config"
+	"github.com/wake/tmux-session-menu/internal/tmux"
 	"github.com/wake/tmux-session-menu/internal/ui"
 )
 
+// configPath 是使用者設定檔的位置。
+const configPath = "~/.config/tsm/config.toml"
+
 func main() {
-	m := ui.NewModel()
+	popup := flag.Bool("popup", false, "強制在 tmux popup 中開啟")
+	inline := flag.Bool("inline", false, "強制在目前終端全螢幕開啟")
+	resultFile := flag.String("result-file", "", "將選取的 session 寫入檔案而不直接連線（popup 內部使用）")
+	flag.Usage = printUsage
+	flag.Parse()
+
+	if *popup && *inline {
+		fatal("--popup and --inline are mutually exclusive")
+	}
+
+	cfg, err := config.LoadFromFile(config.ExpandPath(configPath))
+	if err != nil {
+		fatal("load config: %v", err)
+	}
+	cfg.ApplyDetect(tmux.DefaultRegistry)
+
+	if flag.NArg() > 0 {
+		ok, err := runSubcommand(cfg, flag.Arg(0), flag.Args()[1:])
+		if !ok {
+			flag.Usage()
+			fatal("unknown command %q", flag.Arg(0))
+		}
+		if err != nil {
+			fatal("%v", err)
+		}
+		return
+	}
+
+	if usePopup(*popup, *inline, tmux.InsideTmux(), tmux.InsidePopup()) && popupAvailable(*popup) {
+		err = runPopup(cfg)
+	} else {
+		err = runInline(cfg, *resultFile)
+	}
+	if err != nil {
+		fatal("%v", err)
+	}
+}
+
+// usePopup 決定是否嘗試以 popup 啟動：--inline 優先，其次 --popup，否則在 tmux 內時使用 popup。
+// 已經在 popup 中（例如以 display-popup -E tsm 綁定按鍵）時直接在其中執行，
+// tmux 不會在已有 popup 的 client 上再開啟一個。
+func usePopup(popup, inline, insideTmux, insidePopup bool) bool {
+	if inline || insidePopup {
+		return false
+	}
+	return popup || insideTmux
+}
+
+// runInline 在目前終端以全螢幕執行 TUI，離開後連線到選取的 session。
+// resultFile 不為空時改為將選取結果寫入檔案，交由呼叫端（popup 啟動器）處理。
+func runInline(cfg config.Config, resultFile string) error {
+	st, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	if days := cfg.HistoryRetentionDays; days > 0 {
+		// 清除失敗不影響 TUI，下次啟動再試
+		st.PruneStatusEvents(time.Now().AddDate(0, 0, -days))
+	}
+
+	deps := ui.Deps{Store: st, Cfg: cfg, Tracker: tmux.NewStatusTracker(cfg.StatusHold())}
+	// 優先使用 control mode 連線；沒有任何 session 時退回子程序模式
+	if cc, err := tmux.NewControlClient(); err == nil {
+		defer cc.Close()
+		// 控制連線結束（例如最後一個 session 被關閉）後改以子程序執行，輪詢可繼續運作
+		cc.SetFallback(tmux.NewRealExecutor())
+		deps.TmuxMgr = tmux.NewManager(cc)
+		deps.Events = cc.Events()
+	} else {
+		deps.TmuxMgr = newManager()
+	}
+
+	if hw, err := tmux.NewHookWatcher(cfg.StatusDir()); err == nil {
+		defer hw.Close()
+		deps.Hooks = hw.Updates()
+	}
+
+	m := ui.NewModel(deps)
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
-	if _, err := p.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	final, err := p.Run()
+	if err != nil {
+		return err
 	}
+
+	fm, ok := final.(ui.Model)
+	if !ok || fm.Selected() == "" {
+		return nil
+	}
+	if resultFile != "" {
+		return os.WriteFile(resultFile, []byte(fm.Selected()), 0600)
+	}
+
+	// TUI 已離開 alt screen，此時才 attach／switch-client。
+	// 使用子程序而非 control mode，switch-client 才會作用在使用者的 client 上。
+	if err := newManager().Attach(fm.Selected()); err != nil {
+		return fmt.Errorf("attach: %w", err)
+	}
+	return nil
+}
+
+// fatal 輸出錯誤訊息並結束程式。
+func fatal(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "Error: "+format+"\n", args...)
+	os.Exit(1)
 }
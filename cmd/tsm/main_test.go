@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsePopup(t *testing.T) {
+	tests := []struct {
+		name                string
+		popup, inline       bool
+		insideTmux, inPopup bool
+		want                bool
+	}{
+		{"outside tmux", false, false, false, false, false},
+		{"inside tmux", false, false, true, false, true},
+		{"forced popup outside tmux", true, false, false, false, true},
+		{"forced inline", false, true, true, false, false},
+		{"already in popup", false, false, true, true, false},
+		{"forced popup in popup", true, false, true, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, usePopup(tt.popup, tt.inline, tt.insideTmux, tt.inPopup))
+		})
+	}
+}
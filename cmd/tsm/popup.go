@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/wake/tmux-session-menu/internal/config"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+)
+
+// popupAvailable 判斷是否能以 popup 模式啟動。
+// 不在 tmux 內或 tmux server 版本過舊時回傳 false；forced 時另外輸出警告。
+func popupAvailable(forced bool) bool {
+	if !tmux.InsideTmux() {
+		fmt.Fprintln(os.Stderr, "Warning: --popup requires running inside tmux, falling back to inline")
+		return false
+	}
+	v, err := newManager().ServerVersion()
+	if err == nil && v.SupportsPopup() {
+		return true
+	}
+	if forced {
+		fmt.Fprintln(os.Stderr, "Warning: tmux server does not support display-popup (requires 3.2+), falling back to inline")
+	}
+	return false
+}
+
+// runPopup 在 tmux display-popup 中以 --inline 重新執行自己，
+// popup 關閉後讀回選取的 session，並在原本的 client 上切換過去。
+func runPopup(cfg config.Config) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locate executable: %w", err)
+	}
+
+	f, err := os.CreateTemp("", "tsm-select-*")
+	if err != nil {
+		return fmt.Errorf("create result file: %w", err)
+	}
+	resultFile := f.Name()
+	f.Close()
+	defer os.Remove(resultFile)
+
+	command := shellQuote(self) + " --inline --result-file " + shellQuote(resultFile)
+	mgr := newManager()
+	if err := mgr.DisplayPopup(cfg.PopupWidth, cfg.PopupHeight, command); err != nil {
+		return fmt.Errorf("display popup: %w", err)
+	}
+
+	data, err := os.ReadFile(resultFile)
+	if err != nil {
+		return fmt.Errorf("read result file: %w", err)
+	}
+	target := strings.TrimSpace(string(data))
+	if target == "" {
+		return nil
+	}
+	return mgr.Attach(target)
+}
+
+// shellQuote 以單引號包住字串，供 tmux 交給 shell 執行。
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
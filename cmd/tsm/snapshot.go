@@ -0,0 +1,317 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wake/tmux-session-menu/internal/config"
+	"github.com/wake/tmux-session-menu/internal/store"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+	"github.com/wake/tmux-session-menu/internal/ui"
+)
+
+// claudeResumeCommand 是 restore --resume 時在 Claude Code pane 中執行的指令。
+const claudeResumeCommand = "claude --resume"
+
+func runSnapshot(cfg config.Config, fs *flag.FlagSet, args []string) error {
+	resume := fs.Bool("resume", false, "restore 時在 Claude Code pane 中執行 "+claudeResumeCommand)
+	if len(args) == 0 {
+		fs.Usage()
+		return errors.New("snapshot: action required")
+	}
+	action := args[0]
+	parseArgs(fs, args[1:])
+	if err := requireArgs(fs, 0); err != nil {
+		return err
+	}
+
+	st, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	switch action {
+	case "save":
+		return saveSnapshot(cfg, st)
+	case "restore":
+		return restoreSnapshot(st, *resume)
+	default:
+		fs.Usage()
+		return fmt.Errorf("snapshot: unknown action %q", action)
+	}
+}
+
+// saveSnapshot 記錄所有 session 的 window、pane、版面、目錄與執行中的指令，取代先前的 snapshot。
+func saveSnapshot(cfg config.Config, st *store.Store) error {
+	mgr := newManager()
+	msg := ui.LoadSessions(ui.Deps{TmuxMgr: mgr, Store: st, Cfg: cfg})
+	if msg.Err != nil {
+		return msg.Err
+	}
+	if len(msg.Sessions) == 0 {
+		// 避免在 tmux server 尚未啟動時以空的 snapshot 覆蓋先前的紀錄
+		return errors.New("snapshot: no sessions to save")
+	}
+	details, err := mgr.PaneDetails()
+	if err != nil {
+		return err
+	}
+	// 無法取得程序列表或 argv 時只記錄 pane_current_command，restore 時不會重新執行指令
+	processes, _ := listProcesses()
+
+	now := time.Now()
+	snaps := make([]store.Snapshot, 0, len(msg.Sessions))
+	for _, s := range msg.Sessions {
+		snap := store.Snapshot{
+			SessionName: s.Name,
+			Path:        s.Path,
+			GroupName:   s.GroupName,
+			SortOrder:   s.SortOrder,
+			CustomName:  s.CustomName,
+			SavedAt:     now,
+		}
+		for _, w := range s.Windows {
+			for _, p := range w.Panes {
+				detail := details[p.ID]
+				if detail.Dir == "" {
+					detail.Dir = s.Path
+				}
+				pane := store.SnapshotPane{
+					WindowIndex: w.Index,
+					WindowName:  w.Name,
+					Layout:      detail.Layout,
+					PaneIndex:   p.Index,
+					Dir:         detail.Dir,
+					Command:     p.Command,
+					Agent:       p.Agent,
+				}
+				if !isShell(p.Command) {
+					pane.Args = commandLine(processes.foreground(p.PID))
+				}
+				snap.Panes = append(snap.Panes, pane)
+			}
+		}
+		snaps = append(snaps, snap)
+	}
+	if err := st.SaveSnapshots(snaps); err != nil {
+		return err
+	}
+	fmt.Printf("Saved %d session(s)\n", len(snaps))
+	return nil
+}
+
+// restoreSnapshot 重建 snapshot 中目前不存在的 session，並還原其群組、排序與自訂名稱。
+// 單一 session 失敗時繼續處理其餘 session。
+func restoreSnapshot(st *store.Store, resume bool) error {
+	snaps, err := st.ListSnapshots()
+	if err != nil {
+		return err
+	}
+	if len(snaps) == 0 {
+		return errors.New("snapshot: nothing saved, run `tsm snapshot save` first")
+	}
+
+	mgr := newManager()
+	// tmux server 未啟動時 list-sessions 會失敗，視為沒有任何 session
+	running := make(map[string]bool)
+	if sessions, err := mgr.ListSessions(); err == nil {
+		for _, s := range sessions {
+			running[s.Name] = true
+		}
+	}
+
+	var errs []error
+	for _, snap := range snaps {
+		if running[snap.SessionName] {
+			fmt.Printf("Skipped %s: already running\n", snap.SessionName)
+			continue
+		}
+		if err := restoreSession(mgr, st, snap, resume); err != nil {
+			errs = append(errs, fmt.Errorf("restore %s: %w", snap.SessionName, err))
+			continue
+		}
+		fmt.Printf("Restored %s\n", snap.SessionName)
+	}
+	return errors.Join(errs...)
+}
+
+// restoreSession 以 snapshot 建立 session 並寫回 session_meta。
+func restoreSession(mgr *tmux.Manager, st *store.Store, snap store.Snapshot, resume bool) error {
+	dir := snap.Path
+	if dir == "" && len(snap.Panes) > 0 {
+		dir = snap.Panes[0].Dir
+	}
+	if err := mgr.NewSessionFromTemplate(snap.SessionName, dir, snapshotTemplate(snap, resume)); err != nil {
+		return err
+	}
+
+	var err error
+	if snap.GroupName != "" {
+		err = st.AssignSessionGroup(snap.SessionName, snap.GroupName, snap.SortOrder)
+	} else {
+		err = st.SetSessionGroup(snap.SessionName, 0, snap.SortOrder)
+	}
+	if err != nil {
+		return err
+	}
+	if snap.CustomName != "" {
+		return st.SetSessionCustomName(snap.SessionName, snap.CustomName)
+	}
+	return nil
+}
+
+// snapshotTemplate 將 snapshot 轉為範本：pane 依序分割後套用記錄的版面，
+// 並在各 pane 中重新執行原本的指令。
+func snapshotTemplate(snap store.Snapshot, resume bool) tmux.Template {
+	tpl := tmux.Template{Name: "snapshot " + snap.SessionName}
+	for i, p := range snap.Panes {
+		if i == 0 || p.WindowIndex != snap.Panes[i-1].WindowIndex {
+			w := tmux.TemplateWindow{Name: p.WindowName, Layout: p.Layout}
+			if w.Name == p.Command {
+				w.Name = "" // 自動命名的 window 不指定名稱，保留 tmux 的 automatic-rename
+			}
+			tpl.Windows = append(tpl.Windows, w)
+		}
+		w := &tpl.Windows[len(tpl.Windows)-1]
+		w.Panes = append(w.Panes, tmux.TemplatePane{Dir: p.Dir, Command: relaunchCommand(p, resume)})
+	}
+	return tpl
+}
+
+// relaunchCommand 回傳 restore 時要在 pane 中執行的指令；shell 不執行任何指令。
+func relaunchCommand(p store.SnapshotPane, resume bool) string {
+	if isShell(p.Command) {
+		return ""
+	}
+	if resume && p.Agent == tmux.ClaudeCode.Name() {
+		return claudeResumeCommand
+	}
+	return p.Args
+}
+
+// isShell 判斷 pane_current_command 是否為一般 shell。
+func isShell(command string) bool {
+	return tmux.Shell.Match(tmux.AgentInput{Command: command})
+}
+
+// process 是 ps 列出的一個程序。
+type process struct {
+	pid, ppid int
+	pgid      int // 程序群組
+	tpgid     int // 控制終端機的前景程序群組，沒有控制終端機時為 -1 或 0
+}
+
+// processList 是目前所有程序。
+type processList []process
+
+// listProcesses 以 ps 列出所有程序的 pid、父程序與程序群組。
+func listProcesses() (processList, error) {
+	out, err := exec.Command("ps", "-A", "-o", "pid=,ppid=,pgid=,tpgid=").Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseProcesses(string(out)), nil
+}
+
+// parseProcesses 解析 ps -o pid=,ppid=,pgid=,tpgid= 的輸出。
+func parseProcesses(out string) processList {
+	var list processList
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			continue
+		}
+		var nums [4]int
+		ok := true
+		for i, f := range fields {
+			n, err := strconv.Atoi(f)
+			if err != nil {
+				ok = false
+				break
+			}
+			nums[i] = n
+		}
+		if ok {
+			list = append(list, process{pid: nums[0], ppid: nums[1], pgid: nums[2], tpgid: nums[3]})
+		}
+	}
+	return list
+}
+
+// foreground 回傳 pane 中前景程序的 pid：以 pane 程序所在終端機的前景程序群組（tpgid）
+// 找出群組 leader，leader 已結束時取同群組中 pid 最小的程序。
+// 找不到前景群組時為 pane 程序本身（例如以指令直接建立的 pane），pane 程序不存在時回傳 0。
+func (l processList) foreground(panePID int) int {
+	self, tpgid := 0, 0
+	for _, p := range l {
+		if p.pid == panePID {
+			self, tpgid = p.pid, p.tpgid
+			break
+		}
+	}
+	if tpgid <= 0 {
+		return self
+	}
+	member := 0
+	for _, p := range l {
+		if p.pid == tpgid {
+			return p.pid
+		}
+		if p.pgid == tpgid && (member == 0 || p.pid < member) {
+			member = p.pid
+		}
+	}
+	if member != 0 {
+		return member
+	}
+	return self
+}
+
+// procDir 是讀取程序 argv 的 procfs 位置，測試時可替換。
+var procDir = "/proc"
+
+// commandLine 讀取程序的 argv（/proc/<pid>/cmdline，以 NUL 分隔），回傳各參數加上 shell 引號的指令列，
+// 讓 restore 時交給 shell 重新解析仍得到相同的 argv。
+// 無法取得 argv（pid 為 0、程序已結束或系統沒有 procfs）時回傳空字串，restore 時不重新執行；
+// ps 的 args 欄位以空白合併參數，無法還原參數邊界，因此不作為替代。
+func commandLine(pid int) string {
+	if pid <= 0 {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(procDir, strconv.Itoa(pid), "cmdline"))
+	if err != nil || len(data) == 0 {
+		return ""
+	}
+	argv := strings.Split(strings.TrimSuffix(string(data), "\x00"), "\x00")
+	return shellJoin(argv)
+}
+
+// shellJoin 將 argv 組成 shell 指令列，只含一般字元的參數維持原樣，其餘以單引號包住。
+func shellJoin(argv []string) string {
+	words := make([]string, len(argv))
+	for i, a := range argv {
+		if a != "" && strings.IndexFunc(a, isShellUnsafe) < 0 {
+			words[i] = a
+		} else {
+			words[i] = shellQuote(a)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// isShellUnsafe 回報字元在 shell 中是否需要引號才能保持原義。
+func isShellUnsafe(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return false
+	}
+	return !strings.ContainsRune("/._-+:@%,=", r)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProcesses(t *testing.T) {
+	out := "    1     0     1    -1\n" +
+		"  812   800   812   950\n" +
+		"  950   812   950   950\n" +
+		"  bad line\n"
+
+	assert.Equal(t, processList{
+		{pid: 1, ppid: 0, pgid: 1, tpgid: -1},
+		{pid: 812, ppid: 800, pgid: 812, tpgid: 950},
+		{pid: 950, ppid: 812, pgid: 950, tpgid: 950},
+	}, parseProcesses(out))
+}
+
+func TestProcessList_Foreground(t *testing.T) {
+	list := processList{
+		// pane 1：shell 在背景執行 sleep，前景為 claude
+		{pid: 100, ppid: 1, pgid: 100, tpgid: 120},
+		{pid: 110, ppid: 100, pgid: 110, tpgid: 120},
+		{pid: 120, ppid: 100, pgid: 120, tpgid: 120},
+		{pid: 121, ppid: 120, pgid: 120, tpgid: 120},
+		// pane 2：shell 正在等待輸入
+		{pid: 200, ppid: 1, pgid: 200, tpgid: 200},
+		// pane 3：前景群組的 leader 已結束
+		{pid: 300, ppid: 1, pgid: 300, tpgid: 310},
+		{pid: 312, ppid: 300, pgid: 310, tpgid: 310},
+		{pid: 311, ppid: 300, pgid: 310, tpgid: 310},
+	}
+
+	assert.Equal(t, 120, list.foreground(100))
+	assert.Equal(t, 200, list.foreground(200))
+	assert.Equal(t, 311, list.foreground(300))
+	assert.Equal(t, 0, list.foreground(999))
+}
+
+func TestCommandLine(t *testing.T) {
+	dir := t.TempDir()
+	old := procDir
+	procDir = dir
+	t.Cleanup(func() { procDir = old })
+
+	argv := []string{"bash", "-c", "a; b", "it's", "$HOME", "", "--flag=x"}
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "42"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "42", "cmdline"), []byte(strings.Join(argv, "\x00")+"\x00"), 0644))
+
+	line := commandLine(42)
+	assert.Equal(t, `bash -c 'a; b' 'it'\''s' '$HOME' '' --flag=x`, line)
+
+	// shell 重新解析後得到相同的 argv
+	out, err := exec.Command("sh", "-c", "f() { for a in \"$@\"; do printf '%s\\0' \"$a\"; done; }; f "+line).Output()
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join(argv, "\x00")+"\x00", string(out))
+
+	// 無法取得 argv 時不重新執行
+	assert.Equal(t, "", commandLine(43))
+	assert.Equal(t, "", commandLine(0))
+}
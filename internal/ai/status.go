@@ -1,32 +1,46 @@
 package ai
 
 import (
-	"regexp"
 	"strings"
-)
 
-var claudeModelPattern = regexp.MustCompile(`claude-(?:sonnet|opus|haiku)-[\w.-]+`)
+	"github.com/wake/tmux-session-menu/internal/tmux"
+)
 
+// DetectModel 從終端內容擷取 Claude 模型名稱。
 func DetectModel(content string) string {
-	return claudeModelPattern.FindString(content)
+	return tmux.ClaudeCode.Model(content)
 }
 
+// DetectTool 回傳擁有此 pane 的 agent 名稱（例如 "claude-code"、"codex"），
+// 一般 shell 或無法判斷時回傳空字串。
 func DetectTool(content string) string {
-	claudeIndicators := []string{
-		"ctrl+c to interrupt",
-		"esc to interrupt",
-	}
-	for _, indicator := range claudeIndicators {
-		if strings.Contains(content, indicator) {
-			return "claude-code"
+	return DetectPaneTool(tmux.AgentInput{Content: content})
+}
+
+// DetectPaneTool 與 DetectTool 相同，但可額外以 pane 指令判斷。
+func DetectPaneTool(in tmux.AgentInput) string {
+	in.Content = tmux.StripANSI(in.Content)
+	if d := tmux.DetectAgent(in); d != nil {
+		if d.Name() == tmux.Shell.Name() {
+			return ""
 		}
+		return d.Name()
 	}
-	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
-	if len(lines) > 0 {
-		last := strings.TrimSpace(lines[len(lines)-1])
-		if last == ">" || last == "\u2771" {
-			return "claude-code"
-		}
+	// 只剩提示字元時無法從內容判斷，沿用 Claude Code 的提示字元
+	lines := strings.Split(strings.TrimRight(in.Content, "\n"), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if last == ">" || last == "\u2771" || last == "❯" {
+		return tmux.ClaudeCode.Name()
 	}
 	return ""
 }
+
+// DetectPaneModel 以 pane 所屬 agent 的規則擷取模型名稱，無法判斷 agent 時使用 Claude 的規則。
+func DetectPaneModel(in tmux.AgentInput) string {
+	in.Content = tmux.StripANSI(in.Content)
+	d := tmux.DetectAgent(in)
+	if d == nil {
+		d = tmux.ClaudeCode
+	}
+	return d.Model(in.Content)
+}
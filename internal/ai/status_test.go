@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/wake/tmux-session-menu/internal/ai"
+	"github.com/wake/tmux-session-menu/internal/tmux"
 )
 
 func TestDetectModel(t *testing.T) {
@@ -37,6 +38,9 @@ func TestDetectTool(t *testing.T) {
 		{"claude code interrupt", "ctrl+c to interrupt", "claude-code"},
 		{"plain shell", "user@host:~$", ""},
 		{"gemini", "gemini>", ""},
+		{"codex banner", ">_ OpenAI Codex (v0.40)", "codex"},
+		{"gemini cli", "Thinking (esc to cancel, 4s)", "gemini"},
+		{"aider", "Aider v0.86.1", "aider"},
 	}
 
 	for _, tt := range tests {
@@ -45,3 +49,13 @@ func TestDetectTool(t *testing.T) {
 		})
 	}
 }
+
+func TestDetectPaneTool(t *testing.T) {
+	assert.Equal(t, "codex", ai.DetectPaneTool(tmux.AgentInput{Command: "codex"}))
+	assert.Equal(t, "", ai.DetectPaneTool(tmux.AgentInput{Command: "zsh", Content: "❯"}))
+}
+
+func TestDetectPaneModel(t *testing.T) {
+	assert.Equal(t, "gemini-2.5-flash", ai.DetectPaneModel(tmux.AgentInput{Command: "gemini", Content: "gemini-2.5-flash"}))
+	assert.Equal(t, "claude-sonnet-4-6", ai.DetectPaneModel(tmux.AgentInput{Content: "\033[2mclaude-sonnet-4-6\033[0m"}))
+}
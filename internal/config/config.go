@@ -1,10 +1,19 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/wake/tmux-session-menu/internal/tmux"
 )
 
 // Config 是 TSM 的全域設定。
@@ -12,6 +21,76 @@ type Config struct {
 	DataDir         string `toml:"data_dir"`
 	PreviewLines    int    `toml:"preview_lines"`
 	PollIntervalSec int    `toml:"poll_interval_sec"`
+	PopupWidth      string `toml:"popup_width"`
+	PopupHeight     string `toml:"popup_height"`
+
+	// HistoryRetentionDays 是狀態歷史紀錄保留的天數，0 表示不清除。
+	HistoryRetentionDays int `toml:"history_retention_days"`
+
+	// SideBySideWidth 是改為左右並排（左側列表、右側預覽）的最小終端寬度，0 表示永遠上下排列。
+	SideBySideWidth int `toml:"side_by_side_width"`
+
+	// Debounce 是狀態防抖動設定。
+	Debounce DebounceConfig `toml:"debounce"`
+
+	// Detect 是自訂的狀態偵測規則，以 agent 名稱為鍵（例如 "claude-code"），
+	// 預設合併到同名的內建規則之上；名稱不存在時新增一個 agent。
+	Detect map[string]DetectRule `toml:"detect"`
+
+	// Templates 是 [[template]] 定義的 session 範本，依設定檔中的順序排列。
+	Templates []Template `toml:"template"`
+}
+
+// DebounceConfig 設定各狀態在偵測不到之後仍維持的秒數。
+// 轉換到更需要注意的狀態（閒置 → 執行中 → 錯誤 → 等待）永遠立即回報。
+type DebounceConfig struct {
+	RunningHoldSec int `toml:"running_hold_sec"`
+	WaitingHoldSec int `toml:"waiting_hold_sec"`
+	ErrorHoldSec   int `toml:"error_hold_sec"`
+}
+
+// DetectRule 是單一 agent 的偵測規則。樣式為一般字串（子字串比對），
+// 以 "re:" 開頭者視為正規表達式。
+type DetectRule struct {
+	Commands   []string       `toml:"commands"`   // pane_current_command 比對
+	Markers    []tmux.Pattern `toml:"markers"`    // 內容標記，用於辨識 agent
+	Busy       []tmux.Pattern `toml:"busy"`       // 執行中指標
+	Waiting    []tmux.Pattern `toml:"waiting"`    // 等待輸入指標
+	Permission []tmux.Pattern `toml:"permission"` // 工具權限確認（等待原因 permission）
+	Plan       []tmux.Pattern `toml:"plan"`       // 計畫確認（等待原因 plan）
+	Question   []tmux.Pattern `toml:"question"`   // Y/n 等問題（等待原因 question）
+	Error      []tmux.Pattern `toml:"error"`      // 錯誤指標
+	Prompts    []string       `toml:"prompts"`    // 提示字元（最後一行完全相等）
+	TailLines  int            `toml:"tail_lines"` // 只檢查最後幾行（0 表示沿用內建設定）
+	Model      tmux.Pattern   `toml:"model"`      // 模型名稱樣式，有擷取群組時取第一個
+	Replace    bool           `toml:"replace"`    // 取代內建規則而非合併
+}
+
+// Template 是一個 session 範本：依序建立的 window 與各 window 中的 pane。
+// 工作目錄可用 ~ 開頭，相對路徑以建立 session 時指定的目錄為基準。
+type Template struct {
+	Name        string            `toml:"name"`
+	Description string            `toml:"description"`
+	Env         map[string]string `toml:"env"`
+	Windows     []TemplateWindow  `toml:"window"`
+}
+
+// TemplateWindow 是範本中的一個 window。
+type TemplateWindow struct {
+	Name   string            `toml:"name"`
+	Dir    string            `toml:"dir"`
+	Layout string            `toml:"layout"` // select-layout 的版面，例如 main-vertical、tiled
+	Env    map[string]string `toml:"env"`
+	Panes  []TemplatePane    `toml:"pane"`
+}
+
+// TemplatePane 是 window 中的一個 pane，第一個以外的 pane 從前一個 pane 分割出來。
+type TemplatePane struct {
+	Split   string            `toml:"split"` // "horizontal"（左右）或 "vertical"（上下，預設）
+	Size    string            `toml:"size"`  // 例如 "30%"
+	Dir     string            `toml:"dir"`
+	Command string            `toml:"command"`
+	Env     map[string]string `toml:"env"`
 }
 
 // Default 回傳預設設定。
@@ -20,18 +99,289 @@ func Default() Config {
 		DataDir:         "~/.config/tsm",
 		PreviewLines:    150,
 		PollIntervalSec: 2,
+		PopupWidth:      "80%",
+		PopupHeight:     "80%",
+		Debounce:        DebounceConfig{RunningHoldSec: 4},
+
+		HistoryRetentionDays: 30,
+		SideBySideWidth:      140,
 	}
 }
 
+// StatusHold 回傳 tmux.NewStatusTracker 所需的各狀態維持時間。
+func (c Config) StatusHold() map[tmux.SessionStatus]time.Duration {
+	return map[tmux.SessionStatus]time.Duration{
+		tmux.StatusRunning: time.Duration(c.Debounce.RunningHoldSec) * time.Second,
+		tmux.StatusWaiting: time.Duration(c.Debounce.WaitingHoldSec) * time.Second,
+		tmux.StatusError:   time.Duration(c.Debounce.ErrorHoldSec) * time.Second,
+	}
+}
+
+// StatusDir 回傳 hook 狀態檔案所在的目錄（已展開 ~）。
+func (c Config) StatusDir() string {
+	return filepath.Join(ExpandPath(c.DataDir), "status")
+}
+
 // LoadFromString 從 TOML 字串載入設定，未指定欄位使用預設值。
 func LoadFromString(data string) (Config, error) {
 	cfg := Default()
-	if _, err := toml.Decode(data, &cfg); err != nil {
+	md, err := toml.Decode(data, &cfg)
+	if err != nil {
+		return Config{}, err
+	}
+	if cfg.HistoryRetentionDays < 0 {
+		return Config{}, keyError(data, toml.Key{"history_retention_days"}, "must not be negative")
+	}
+	if cfg.SideBySideWidth < 0 {
+		return Config{}, keyError(data, toml.Key{"side_by_side_width"}, "must not be negative")
+	}
+	if err := validateDebounce(data, cfg.Debounce); err != nil {
+		return Config{}, err
+	}
+	if err := validateDetect(data, md, cfg.Detect); err != nil {
+		return Config{}, err
+	}
+	if err := validateTemplates(data, md, cfg.Templates); err != nil {
 		return Config{}, err
 	}
 	return cfg, nil
 }
 
+// validateDebounce 檢查 [debounce] 的秒數不為負。
+func validateDebounce(data string, d DebounceConfig) error {
+	for key, sec := range map[string]int{
+		"running_hold_sec": d.RunningHoldSec,
+		"waiting_hold_sec": d.WaitingHoldSec,
+		"error_hold_sec":   d.ErrorHoldSec,
+	} {
+		if sec < 0 {
+			return keyError(data, toml.Key{"debounce", key}, "must not be negative")
+		}
+	}
+	return nil
+}
+
+// validateDetect 檢查 [detect] 區段：不允許未知的鍵、負的 tail_lines，
+// 以及無法辨識的新 agent。樣式語法錯誤已在解碼時回報。
+func validateDetect(data string, md toml.MetaData, rules map[string]DetectRule) error {
+	for _, key := range md.Undecoded() {
+		if len(key) > 0 && key[0] == "detect" {
+			return keyError(data, key, "unknown detect key")
+		}
+	}
+	for _, name := range sortedNames(rules) {
+		rule := rules[name]
+		if rule.TailLines < 0 {
+			return keyError(data, toml.Key{"detect", name, "tail_lines"}, "must not be negative")
+		}
+		isNew := rule.Replace || tmux.DefaultRegistry.Lookup(name) == nil
+		if isNew && len(rule.Commands) == 0 && len(rule.Markers) == 0 {
+			return keyError(data, toml.Key{"detect", name}, "agent needs commands or markers to be detected")
+		}
+	}
+	return nil
+}
+
+// validateTemplates 檢查 [[template]]：不允許未知的鍵、缺少或重複的名稱，
+// 以及無法辨識的分割方向。
+func validateTemplates(data string, md toml.MetaData, templates []Template) error {
+	for _, key := range md.Undecoded() {
+		if len(key) > 0 && key[0] == "template" {
+			return keyError(data, key, "unknown template key")
+		}
+	}
+	seen := make(map[string]bool)
+	for ti, t := range templates {
+		switch {
+		case t.Name == "":
+			return keyError(data, toml.Key{"template"}, "template needs a name", ti)
+		case seen[t.Name]:
+			return keyError(data, toml.Key{"template", "name"}, fmt.Sprintf("duplicate template %q", t.Name), ti)
+		}
+		seen[t.Name] = true
+		for wi, w := range t.Windows {
+			for pi, p := range w.Panes {
+				switch tmux.Split(p.Split) {
+				case "", tmux.SplitVertical, tmux.SplitHorizontal:
+				default:
+					return keyError(data, toml.Key{"template", "window", "pane", "split"},
+						fmt.Sprintf("split must be %q or %q, got %q", tmux.SplitHorizontal, tmux.SplitVertical, p.Split),
+						ti, wi, pi)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// keyError 產生與 toml 解析錯誤相同格式、含行號的錯誤。
+// index 指定 key 路徑上各個陣列表格（[[...]]）的元素位置，見 keyLine。
+func keyError(data string, key toml.Key, msg string, index ...int) error {
+	if line := keyLine(data, key, index...); line > 0 {
+		return fmt.Errorf("toml: line %d (last key %q): %s", line, key.String(), msg)
+	}
+	return fmt.Errorf("toml: (last key %q): %s", key.String(), msg)
+}
+
+// keyLine 找出 key 在 TOML 文件中定義的行號（從 1 開始），找不到時回傳 0。
+// 只處理一般的表格標頭與 key = value 寫法，足以定位設定檔中的錯誤。
+// index 依序指定 key 路徑上各個陣列表格的元素位置（從 0 開始），例如第 2 個 [[template]]
+// 的第 1 個 [[template.window]] 為 1, 0；未指定的層級比對第一個出現處。
+func keyLine(data string, key toml.Key, index ...int) int {
+	var (
+		table  []string
+		elems  []int               // 目前表格在各陣列表格層級的元素位置
+		counts = map[string]int{}  // 陣列表格在目前父元素中出現的次數
+		arrays = map[string]bool{} // 出現過的陣列表格
+	)
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "["):
+			header := strings.Trim(line, "[] ")
+			if end := strings.Index(header, "]"); end >= 0 {
+				header = header[:end]
+			}
+			table = splitKey(header)
+			if path := strings.Join(table, "."); strings.HasPrefix(line, "[[") {
+				counts[path]++
+				arrays[path] = true
+				// 新的陣列元素開始時，其下的陣列表格重新計數
+				for p := range counts {
+					if strings.HasPrefix(p, path+".") {
+						delete(counts, p)
+					}
+				}
+			}
+			elems = elems[:0]
+			for n := 1; n <= len(table); n++ {
+				if path := strings.Join(table[:n], "."); arrays[path] {
+					elems = append(elems, counts[path]-1)
+				}
+			}
+			if slices.Equal(table, key) && matchIndex(elems, index) {
+				return i + 1
+			}
+		default:
+			k, _, ok := strings.Cut(line, "=")
+			if !ok {
+				continue
+			}
+			full := append(append([]string(nil), table...), splitKey(k)...)
+			if slices.Equal(full, key) && matchIndex(elems, index) {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
+
+// matchIndex 判斷目前的元素位置是否符合 index 指定的層級。
+func matchIndex(elems, index []int) bool {
+	if len(index) > len(elems) {
+		return false
+	}
+	return slices.Equal(elems[:len(index)], index)
+}
+
+// splitKey 將以 . 分隔的 TOML key 拆開並去除空白與引號。
+func splitKey(s string) []string {
+	parts := strings.Split(s, ".")
+	for i, p := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(p), `"'`)
+	}
+	return parts
+}
+
+// sortedNames 回傳依名稱排序的 agent 名稱。
+func sortedNames(rules map[string]DetectRule) []string {
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyDetect 將 [detect] 中的自訂規則註冊到 r。
+// 與內建 agent 同名時合併（replace = true 時取代），其餘新增為新的 agent，優先於內建規則。
+func (c Config) ApplyDetect(r *tmux.Registry) {
+	for _, name := range sortedNames(c.Detect) {
+		d := c.Detect[name].detector(name)
+		if base, ok := r.Lookup(name).(*tmux.PatternDetector); ok && !c.Detect[name].Replace {
+			d = base.Merge(d)
+		}
+		r.Register(d)
+	}
+}
+
+// detector 將規則轉為 tmux.PatternDetector。
+func (rule DetectRule) detector(name string) *tmux.PatternDetector {
+	d := &tmux.PatternDetector{
+		AgentName:  name,
+		Commands:   rule.Commands,
+		Markers:    rule.Markers,
+		Busy:       rule.Busy,
+		Waiting:    rule.Waiting,
+		Permission: rule.Permission,
+		Plan:       rule.Plan,
+		Question:   rule.Question,
+		Error:      rule.Error,
+		Prompts:    rule.Prompts,
+		TailLines:  rule.TailLines,
+	}
+	switch {
+	case rule.Model.Regexp != nil:
+		d.ModelPattern = rule.Model.Regexp
+	case rule.Model.Literal != "":
+		d.ModelPattern = regexp.MustCompile(regexp.QuoteMeta(rule.Model.Literal))
+	}
+	return d
+}
+
+// FindTemplate 回傳指定名稱的範本（工作目錄已展開 ~）。
+func (c Config) FindTemplate(name string) (tmux.Template, bool) {
+	for _, t := range c.Templates {
+		if t.Name == name {
+			return t.layout(), true
+		}
+	}
+	return tmux.Template{}, false
+}
+
+// layout 將範本轉為 tmux.Template。
+func (t Template) layout() tmux.Template {
+	out := tmux.Template{Name: t.Name, Env: t.Env}
+	for _, w := range t.Windows {
+		win := tmux.TemplateWindow{Name: w.Name, Dir: ExpandPath(w.Dir), Layout: w.Layout, Env: w.Env}
+		for _, p := range w.Panes {
+			win.Panes = append(win.Panes, tmux.TemplatePane{
+				Split:   tmux.Split(p.Split),
+				Size:    p.Size,
+				Dir:     ExpandPath(p.Dir),
+				Command: p.Command,
+				Env:     p.Env,
+			})
+		}
+		out.Windows = append(out.Windows, win)
+	}
+	return out
+}
+
+// LoadFromFile 從 TOML 檔案載入設定，檔案不存在時回傳預設值。
+func LoadFromFile(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Default(), nil
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	return LoadFromString(string(data))
+}
+
 // ExpandPath 將 ~ 展開為使用者家目錄。
 func ExpandPath(path string) string {
 	if strings.HasPrefix(path, "~/") {
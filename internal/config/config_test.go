@@ -2,11 +2,14 @@ package config_test
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/wake/tmux-session-menu/internal/config"
+	"github.com/wake/tmux-session-menu/internal/tmux"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -15,6 +18,10 @@ func TestDefaultConfig(t *testing.T) {
 	assert.Equal(t, "~/.config/tsm", cfg.DataDir)
 	assert.Equal(t, 150, cfg.PreviewLines)
 	assert.Equal(t, 2, cfg.PollIntervalSec)
+	assert.Equal(t, "80%", cfg.PopupWidth)
+	assert.Equal(t, "80%", cfg.PopupHeight)
+	assert.Equal(t, 30, cfg.HistoryRetentionDays)
+	assert.Equal(t, 140, cfg.SideBySideWidth)
 }
 
 func TestLoadFromTOML(t *testing.T) {
@@ -22,13 +29,19 @@ func TestLoadFromTOML(t *testing.T) {
 data_dir = "/tmp/tsm-test"
 preview_lines = 50
 poll_interval_sec = 5
+popup_width = "120"
+popup_height = "70%"
+side_by_side_width = 0
 `
 	cfg, err := config.LoadFromString(tomlData)
 	require.NoError(t, err)
+	assert.Equal(t, 0, cfg.SideBySideWidth)
 
 	assert.Equal(t, "/tmp/tsm-test", cfg.DataDir)
 	assert.Equal(t, 50, cfg.PreviewLines)
 	assert.Equal(t, 5, cfg.PollIntervalSec)
+	assert.Equal(t, "120", cfg.PopupWidth)
+	assert.Equal(t, "70%", cfg.PopupHeight)
 }
 
 func TestLoadFromTOML_PartialOverride(t *testing.T) {
@@ -47,6 +60,28 @@ func TestLoadFromTOML_InvalidInput(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestLoadFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte("poll_interval_sec = 7"), 0644))
+
+	cfg, err := config.LoadFromFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 7, cfg.PollIntervalSec)
+	assert.Equal(t, 150, cfg.PreviewLines)
+}
+
+func TestLoadFromFile_Missing(t *testing.T) {
+	cfg, err := config.LoadFromFile(filepath.Join(t.TempDir(), "nope.toml"))
+	require.NoError(t, err)
+	assert.Equal(t, config.Default(), cfg)
+}
+
+func TestStatusDir(t *testing.T) {
+	cfg := config.Default()
+	cfg.DataDir = "/tmp/tsm"
+	assert.Equal(t, "/tmp/tsm/status", cfg.StatusDir())
+}
+
 func TestExpandPath(t *testing.T) {
 	home, _ := os.UserHomeDir()
 
@@ -66,3 +101,228 @@ func TestExpandPath(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadDetectRules(t *testing.T) {
+	tomlData := `
+[detect.claude-code]
+busy = ["* Flibbertigibbeting", "re:^\\* \\w+ing…"]
+error = ["re:(?i)overloaded"]
+tail_lines = 20
+
+[detect.my-agent]
+commands = ["myai"]
+waiting = ["Approve?"]
+permission = ["re:^Run .+\\?$"]
+prompts = ["$$"]
+model = "re:model=(\\S+)"
+`
+	cfg, err := config.LoadFromString(tomlData)
+	require.NoError(t, err)
+	require.Len(t, cfg.Detect, 2)
+
+	claude := cfg.Detect["claude-code"]
+	require.Len(t, claude.Busy, 2)
+	assert.Equal(t, "* Flibbertigibbeting", claude.Busy[0].Literal)
+	assert.NotNil(t, claude.Busy[1].Regexp)
+	assert.Equal(t, 20, claude.TailLines)
+	assert.Equal(t, []string{"myai"}, cfg.Detect["my-agent"].Commands)
+	require.Len(t, cfg.Detect["my-agent"].Permission, 1)
+	assert.NotNil(t, cfg.Detect["my-agent"].Permission[0].Regexp)
+}
+
+func TestLoadDetectRules_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name:    "invalid regexp",
+			data:    "poll_interval_sec = 2\n[detect.claude-code]\nbusy = [\"re:(unclosed\"]\n",
+			wantErr: "line 3",
+		},
+		{
+			name:    "empty pattern",
+			data:    "[detect.codex]\n\nwaiting = [\"\"]\n",
+			wantErr: "line 3",
+		},
+		{
+			name:    "unknown key",
+			data:    "[detect.aider]\nbussy = [\"x\"]\n",
+			wantErr: "line 2",
+		},
+		{
+			name:    "negative tail",
+			data:    "[detect]\ngemini.tail_lines = -1\n",
+			wantErr: "line 2",
+		},
+		{
+			name:    "new agent without matcher",
+			data:    "\n[detect.mystery]\nbusy = [\"working\"]\n",
+			wantErr: "line 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := config.LoadFromString(tt.data)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestApplyDetect(t *testing.T) {
+	cfg, err := config.LoadFromString(`
+[detect.claude-code]
+busy = ["* Flibbertigibbeting"]
+
+[detect.my-agent]
+commands = ["myai"]
+waiting = ["Approve?"]
+model = "re:model=(\\S+)"
+
+[detect.aider]
+replace = true
+commands = ["aider"]
+busy = ["re:^Tokens:"]
+`)
+	require.NoError(t, err)
+
+	reg := tmux.NewRegistry(tmux.Shell, tmux.Aider, tmux.ClaudeCode)
+	cfg.ApplyDetect(reg)
+
+	// 合併：自訂指標生效，內建指標仍保留
+	claude := reg.Lookup("claude-code")
+	assert.Equal(t, tmux.StatusRunning, claude.Status("* Flibbertigibbeting… (3s)"))
+	assert.Equal(t, tmux.StatusRunning, claude.Status("esc to interrupt"))
+	assert.Equal(t, tmux.StatusIdle, tmux.ClaudeCode.Status("* Flibbertigibbeting… (3s)"), "built-in must not be modified")
+
+	// 新 agent
+	mine := reg.Detect(tmux.AgentInput{Command: "myai"})
+	require.NotNil(t, mine)
+	assert.Equal(t, "my-agent", mine.Name())
+	assert.Equal(t, tmux.StatusWaiting, mine.Status("Approve? [y/n]"))
+	assert.Equal(t, "m1", mine.Model("model=m1"))
+
+	// 取代：內建的等待指標不再生效
+	aider := reg.Lookup("aider")
+	assert.Equal(t, tmux.StatusIdle, aider.Status("(Y)es/(N)o"))
+	assert.Equal(t, tmux.StatusRunning, aider.Status("Tokens: 2k sent"))
+}
+
+func TestLoadDebounce(t *testing.T) {
+	cfg := config.Default()
+	assert.Equal(t, 4*time.Second, cfg.StatusHold()[tmux.StatusRunning])
+	assert.Zero(t, cfg.StatusHold()[tmux.StatusWaiting])
+
+	cfg, err := config.LoadFromString("[debounce]\nrunning_hold_sec = 6\nerror_hold_sec = 2\n")
+	require.NoError(t, err)
+	assert.Equal(t, 6*time.Second, cfg.StatusHold()[tmux.StatusRunning])
+	assert.Equal(t, 2*time.Second, cfg.StatusHold()[tmux.StatusError])
+
+	_, err = config.LoadFromString("[debounce]\n\nwaiting_hold_sec = -1\n")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 3")
+}
+
+func TestLoadTemplates(t *testing.T) {
+	tomlData := `
+[[template]]
+name = "dev"
+description = "editor + claude + server"
+env = { APP_ENV = "dev" }
+
+[[template.window]]
+name = "code"
+layout = "main-vertical"
+
+[[template.window.pane]]
+command = "nvim"
+
+[[template.window.pane]]
+split = "horizontal"
+size = "40%"
+command = "claude"
+
+[[template.window]]
+name = "server"
+dir = "~/web"
+
+[[template.window.pane]]
+command = "npm run dev"
+env = { PORT = "3000" }
+
+[[template]]
+name = "scratch"
+`
+	cfg, err := config.LoadFromString(tomlData)
+	require.NoError(t, err)
+	require.Len(t, cfg.Templates, 2)
+	assert.Equal(t, "editor + claude + server", cfg.Templates[0].Description)
+
+	tpl, ok := cfg.FindTemplate("dev")
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"APP_ENV": "dev"}, tpl.Env)
+	require.Len(t, tpl.Windows, 2)
+	assert.Equal(t, "main-vertical", tpl.Windows[0].Layout)
+	require.Len(t, tpl.Windows[0].Panes, 2)
+	assert.Equal(t, tmux.SplitHorizontal, tpl.Windows[0].Panes[1].Split)
+	assert.Equal(t, "40%", tpl.Windows[0].Panes[1].Size)
+	assert.Equal(t, config.ExpandPath("~/web"), tpl.Windows[1].Dir)
+	assert.Equal(t, map[string]string{"PORT": "3000"}, tpl.Windows[1].Panes[0].Env)
+
+	_, ok = cfg.FindTemplate("missing")
+	assert.False(t, ok)
+}
+
+func TestLoadTemplates_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name:    "missing name",
+			data:    "[[template]]\ndescription = \"x\"\n",
+			wantErr: "needs a name",
+		},
+		{
+			name:    "duplicate name",
+			data:    "[[template]]\nname = \"dev\"\n[[template]]\nname = \"dev\"\n",
+			wantErr: "duplicate template",
+		},
+		{
+			name:    "invalid split",
+			data:    "[[template]]\nname = \"dev\"\n[[template.window]]\n[[template.window.pane]]\n[[template.window.pane]]\nsplit = \"diagonal\"\n",
+			wantErr: "line 6",
+		},
+		{
+			name:    "unknown key",
+			data:    "[[template]]\nname = \"dev\"\n[[template.window]]\npanes = 2\n",
+			wantErr: "line 4",
+		},
+		{
+			name:    "missing name in second template",
+			data:    "[[template]]\nname = \"dev\"\n[[template]]\n[[template.window]]\n",
+			wantErr: "line 3",
+		},
+		{
+			name:    "duplicate name in third template",
+			data:    "[[template]]\nname = \"dev\"\n[[template]]\nname = \"ops\"\n[[template]]\nname = \"dev\"\n",
+			wantErr: "line 6",
+		},
+		{
+			name: "invalid split in second template",
+			data: "[[template]]\nname = \"dev\"\n[[template.window]]\n[[template.window.pane]]\nsplit = \"horizontal\"\n" +
+				"[[template]]\nname = \"ops\"\n[[template.window]]\n[[template.window]]\n[[template.window.pane]]\nsplit = \"x\"\n",
+			wantErr: "line 11",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := config.LoadFromString(tt.data)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
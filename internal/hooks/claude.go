@@ -0,0 +1,332 @@
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Events 是 tsm 需要註冊的 Claude Code hook 事件。
+var Events = []string{
+	"SessionStart",
+	"UserPromptSubmit",
+	"Stop",
+	"PermissionRequest",
+	"Notification",
+	"SessionEnd",
+}
+
+// UserSettingsPath 回傳 Claude Code 使用者層級的 settings.json 路徑。
+func UserSettingsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".claude", "settings.json"), nil
+}
+
+// ProjectSettingsPath 回傳專案層級的 settings.json 路徑。
+func ProjectSettingsPath(dir string) string {
+	return filepath.Join(dir, ".claude", "settings.json")
+}
+
+// hookCommand 組出事件對應的指令，例如 "/usr/local/bin/tsm hook Stop"。
+// Claude Code 以 shell 執行 hook，路徑含空白或特殊字元時以單引號包住。
+func hookCommand(tsmPath, event string) string {
+	return shellQuote(tsmPath) + " hook " + event
+}
+
+// shellQuote 在字串含有 shell 特殊字元時以單引號包住，一般路徑維持原樣。
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, isShellUnsafe) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// isShellUnsafe 回報字元在 shell 中是否需要引號才能保持原義。
+func isShellUnsafe(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return false
+	}
+	return !strings.ContainsRune("/._-+:@%,=", r)
+}
+
+// isTsmCommand 判斷 hook 指令是否由 tsm 安裝（執行檔名為 tsm 且子命令為 hook）。
+// 也認得舊版未加引號、路徑含空白的指令，讓重新安裝或移除時能清掉它們。
+func isTsmCommand(cmd string) bool {
+	if words := splitWords(cmd); len(words) >= 2 && filepath.Base(words[0]) == "tsm" && words[1] == "hook" {
+		return true
+	}
+	i := strings.LastIndex(cmd, " hook ")
+	return i > 0 && filepath.Base(cmd[:i]) == "tsm" && !strings.ContainsAny(cmd[i+len(" hook "):], " \t")
+}
+
+// splitWords 依 shell 規則切分指令：處理單引號、雙引號與反斜線跳脫，不展開變數。
+func splitWords(cmd string) []string {
+	var (
+		words   []string
+		cur     strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range cmd {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inWord = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words
+}
+
+// Merge 將 tsm 的 hook 合併進 settings，保留使用者既有的 hook。
+// 已存在但指令不同（例如 tsm 路徑改變）的 tsm hook 會被取代。回傳是否有變更。
+func Merge(settings map[string]any, tsmPath string) bool {
+	hooks, _ := settings["hooks"].(map[string]any)
+	if hooks == nil {
+		hooks = map[string]any{}
+	}
+
+	changed := false
+	for _, event := range Events {
+		want := hookCommand(tsmPath, event)
+		groups, _ := hooks[event].([]any)
+		if containsCommand(groups, want) && countTsmCommands(groups) == 1 {
+			continue
+		}
+		groups = removeTsmCommands(groups)
+		groups = append(groups, map[string]any{
+			"hooks": []any{map[string]any{"type": "command", "command": want}},
+		})
+		hooks[event] = groups
+		changed = true
+	}
+
+	if changed {
+		settings["hooks"] = hooks
+	}
+	return changed
+}
+
+// Remove 從 settings 移除所有 tsm 安裝的 hook，並清掉因此變空的項目。回傳是否有變更。
+func Remove(settings map[string]any) bool {
+	hooks, _ := settings["hooks"].(map[string]any)
+	if hooks == nil {
+		return false
+	}
+
+	changed := false
+	for event, v := range hooks {
+		groups, _ := v.([]any)
+		if countTsmCommands(groups) == 0 {
+			continue
+		}
+		changed = true
+		groups = removeTsmCommands(groups)
+		if len(groups) == 0 {
+			delete(hooks, event)
+		} else {
+			hooks[event] = groups
+		}
+	}
+	if len(hooks) == 0 {
+		delete(settings, "hooks")
+	}
+	return changed
+}
+
+// Installed 回傳每個事件是否已安裝 tsm hook。
+func Installed(settings map[string]any) map[string]bool {
+	hooks, _ := settings["hooks"].(map[string]any)
+	result := make(map[string]bool, len(Events))
+	for _, event := range Events {
+		groups, _ := hooks[event].([]any)
+		result[event] = countTsmCommands(groups) > 0
+	}
+	return result
+}
+
+// forEachCommand 依序走訪 matcher 群組中的每個 hook 物件。
+func forEachCommand(groups []any, fn func(hook map[string]any)) {
+	for _, g := range groups {
+		group, _ := g.(map[string]any)
+		list, _ := group["hooks"].([]any)
+		for _, h := range list {
+			if hook, ok := h.(map[string]any); ok {
+				fn(hook)
+			}
+		}
+	}
+}
+
+func containsCommand(groups []any, cmd string) bool {
+	found := false
+	forEachCommand(groups, func(hook map[string]any) {
+		if hook["command"] == cmd {
+			found = true
+		}
+	})
+	return found
+}
+
+func countTsmCommands(groups []any) int {
+	n := 0
+	forEachCommand(groups, func(hook map[string]any) {
+		if c, _ := hook["command"].(string); isTsmCommand(c) {
+			n++
+		}
+	})
+	return n
+}
+
+// removeTsmCommands 移除 tsm 的 hook，並丟棄因此沒有任何 hook 的 matcher 群組。
+func removeTsmCommands(groups []any) []any {
+	var out []any
+	for _, g := range groups {
+		group, ok := g.(map[string]any)
+		if !ok {
+			out = append(out, g)
+			continue
+		}
+		list, _ := group["hooks"].([]any)
+		var kept []any
+		for _, h := range list {
+			hook, _ := h.(map[string]any)
+			if c, _ := hook["command"].(string); hook != nil && isTsmCommand(c) {
+				continue
+			}
+			kept = append(kept, h)
+		}
+		if len(kept) == 0 && len(list) > 0 {
+			continue
+		}
+		group["hooks"] = kept
+		out = append(out, group)
+	}
+	return out
+}
+
+// Load 讀取 settings.json，檔案不存在時回傳空設定。
+func Load(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]any{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	settings := map[string]any{}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return settings, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&settings); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// Save 寫入 settings.json。既有檔案會先備份為 <path>.bak，並以暫存檔 + rename 取代。
+// 不跳脫 HTML 字元，使用者 hook 指令中的 &&、< 與 > 維持原樣。
+func Save(path string, settings map[string]any) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(settings); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	mode := fs.FileMode(0644)
+	if old, err := os.ReadFile(path); err == nil {
+		if fi, err := os.Stat(path); err == nil {
+			mode = fi.Mode().Perm()
+		}
+		if err := os.WriteFile(path+".bak", old, mode); err != nil {
+			return fmt.Errorf("backup settings: %w", err)
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".settings-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Install 將 tsm hook 安裝到 path 指定的 settings.json。回傳是否有變更。
+func Install(path, tsmPath string) (bool, error) {
+	settings, err := Load(path)
+	if err != nil {
+		return false, err
+	}
+	if !Merge(settings, tsmPath) {
+		return false, nil
+	}
+	return true, Save(path, settings)
+}
+
+// Uninstall 從 path 指定的 settings.json 移除 tsm hook。回傳是否有變更。
+func Uninstall(path string) (bool, error) {
+	settings, err := Load(path)
+	if err != nil {
+		return false, err
+	}
+	if !Remove(settings) {
+		return false, nil
+	}
+	return true, Save(path, settings)
+}
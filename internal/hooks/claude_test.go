@@ -0,0 +1,194 @@
+package hooks_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wake/tmux-session-menu/internal/hooks"
+)
+
+const userSettings = `{
+  "model": "opus",
+  "hooks": {
+    "Stop": [
+      {"hooks": [{"type": "command", "command": "say done"}]}
+    ],
+    "PreToolUse": [
+      {"matcher": "Bash", "hooks": [{"type": "command", "command": "audit.sh"}]}
+    ]
+  }
+}`
+
+func writeSettings(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".claude", "settings.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestInstall_PreservesUserHooks(t *testing.T) {
+	path := writeSettings(t, userSettings)
+
+	changed, err := hooks.Install(path, "/usr/local/bin/tsm")
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	settings, err := hooks.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "opus", settings["model"])
+
+	for event, ok := range hooks.Installed(settings) {
+		assert.True(t, ok, event)
+	}
+
+	h := settings["hooks"].(map[string]any)
+	assert.Len(t, h["Stop"], 2, "使用者的 Stop hook 應保留")
+	assert.Len(t, h["PreToolUse"], 1)
+
+	backup, err := os.ReadFile(path + ".bak")
+	require.NoError(t, err)
+	assert.Equal(t, userSettings, string(backup))
+}
+
+func TestInstall_KeepsShellOperators(t *testing.T) {
+	path := writeSettings(t, `{"hooks": {"Stop": [{"hooks": [{"type": "command", "command": "make test && say ok > /tmp/log < /dev/null"}]}]}}`)
+
+	_, err := hooks.Install(path, "/usr/local/bin/tsm")
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"make test && say ok > /tmp/log < /dev/null"`)
+	assert.NotContains(t, string(data), `\u0026`)
+}
+
+func TestInstall_Idempotent(t *testing.T) {
+	path := writeSettings(t, userSettings)
+
+	_, err := hooks.Install(path, "/usr/local/bin/tsm")
+	require.NoError(t, err)
+	first, _ := os.ReadFile(path)
+
+	changed, err := hooks.Install(path, "/usr/local/bin/tsm")
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	second, _ := os.ReadFile(path)
+	assert.Equal(t, string(first), string(second))
+}
+
+func TestInstall_ReplacesOldPath(t *testing.T) {
+	path := writeSettings(t, `{}`)
+
+	_, err := hooks.Install(path, "/old/tsm")
+	require.NoError(t, err)
+	changed, err := hooks.Install(path, "/new/tsm")
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	settings, _ := hooks.Load(path)
+	stop := settings["hooks"].(map[string]any)["Stop"].([]any)
+	require.Len(t, stop, 1)
+	cmd := stop[0].(map[string]any)["hooks"].([]any)[0].(map[string]any)["command"]
+	assert.Equal(t, "/new/tsm hook Stop", cmd)
+}
+
+// stopCommands 回傳 Stop 事件的所有 hook 指令。
+func stopCommands(t *testing.T, path string) []string {
+	t.Helper()
+	settings, err := hooks.Load(path)
+	require.NoError(t, err)
+	var cmds []string
+	for _, g := range settings["hooks"].(map[string]any)["Stop"].([]any) {
+		for _, h := range g.(map[string]any)["hooks"].([]any) {
+			cmds = append(cmds, h.(map[string]any)["command"].(string))
+		}
+	}
+	return cmds
+}
+
+func TestInstall_PathWithSpace(t *testing.T) {
+	path := writeSettings(t, `{}`)
+
+	_, err := hooks.Install(path, "/Users/me/My Tools/tsm")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"'/Users/me/My Tools/tsm' hook Stop"}, stopCommands(t, path))
+
+	settings, _ := hooks.Load(path)
+	for event, ok := range hooks.Installed(settings) {
+		assert.True(t, ok, event)
+	}
+
+	changed, err := hooks.Install(path, "/Users/me/My Tools/tsm")
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	changed, err = hooks.Uninstall(path)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	settings, _ = hooks.Load(path)
+	assert.NotContains(t, settings, "hooks")
+}
+
+func TestInstall_ReplacesUnquotedPath(t *testing.T) {
+	// 舊版安裝的指令沒有加引號
+	path := writeSettings(t, `{"hooks": {"Stop": [{"hooks": [{"type": "command", "command": "/My Tools/tsm hook Stop"}]}]}}`)
+
+	_, err := hooks.Install(path, "/My Tools/tsm")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"'/My Tools/tsm' hook Stop"}, stopCommands(t, path))
+}
+
+func TestInstall_MissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".claude", "settings.json")
+
+	changed, err := hooks.Install(path, "tsm")
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	_, err = os.Stat(path + ".bak")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUninstall(t *testing.T) {
+	path := writeSettings(t, userSettings)
+	_, err := hooks.Install(path, "/usr/local/bin/tsm")
+	require.NoError(t, err)
+
+	changed, err := hooks.Uninstall(path)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	settings, _ := hooks.Load(path)
+	for event, ok := range hooks.Installed(settings) {
+		assert.False(t, ok, event)
+	}
+	h := settings["hooks"].(map[string]any)
+	assert.Len(t, h["Stop"], 1)
+	assert.NotContains(t, h, "SessionStart")
+
+	changed, err = hooks.Uninstall(path)
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestUninstall_DropsEmptyHooks(t *testing.T) {
+	path := writeSettings(t, `{"model": "opus"}`)
+	_, err := hooks.Install(path, "tsm")
+	require.NoError(t, err)
+	_, err = hooks.Uninstall(path)
+	require.NoError(t, err)
+
+	settings, _ := hooks.Load(path)
+	assert.NotContains(t, settings, "hooks")
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := writeSettings(t, `{not json`)
+	_, err := hooks.Load(path)
+	assert.Error(t, err)
+}
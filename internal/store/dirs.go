@@ -0,0 +1,86 @@
+package store
+
+import (
+	"sort"
+	"time"
+)
+
+// Directory 是曾用來建立 session 的目錄，Rank 為使用次數（會隨總量老化）。
+type Directory struct {
+	Path     string
+	Rank     float64
+	LastUsed time.Time
+}
+
+// maxDirectoryRank 是所有目錄 Rank 總和的上限，超過時全部乘以 0.9 並刪除過低的項目（同 zoxide）。
+const maxDirectoryRank = 10000
+
+// Frecency 依使用次數與最近使用時間計算分數（同 zoxide 的計算方式）。
+func (d Directory) Frecency(now time.Time) float64 {
+	switch age := now.Sub(d.LastUsed); {
+	case age < time.Hour:
+		return d.Rank * 4
+	case age < 24*time.Hour:
+		return d.Rank * 2
+	case age < 7*24*time.Hour:
+		return d.Rank / 2
+	default:
+		return d.Rank / 4
+	}
+}
+
+func (s *Store) TouchDirectory(path string, at time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO directories (path, rank, last_used_ms) VALUES (?, 1, ?)
+		ON CONFLICT(path) DO UPDATE SET rank = rank + 1, last_used_ms = excluded.last_used_ms`,
+		path, at.UnixMilli()); err != nil {
+		return err
+	}
+	var total float64
+	if err := tx.QueryRow("SELECT COALESCE(SUM(rank), 0) FROM directories").Scan(&total); err != nil {
+		return err
+	}
+	if total > maxDirectoryRank {
+		if _, err := tx.Exec("UPDATE directories SET rank = rank * 0.9"); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM directories WHERE rank < 1"); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Store) FrecentDirectories(now time.Time) ([]Directory, error) {
+	rows, err := s.db.Query("SELECT path, rank, last_used_ms FROM directories")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var dirs []Directory
+	for rows.Next() {
+		var d Directory
+		var ms int64
+		if err := rows.Scan(&d.Path, &d.Rank, &ms); err != nil {
+			return nil, err
+		}
+		d.LastUsed = time.UnixMilli(ms)
+		dirs = append(dirs, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(dirs, func(i, j int) bool { return dirs[i].Frecency(now) > dirs[j].Frecency(now) })
+	return dirs, nil
+}
+
+func (s *Store) RemoveDirectory(path string) error {
+	_, err := s.db.Exec("DELETE FROM directories WHERE path = ?", path)
+	return err
+}
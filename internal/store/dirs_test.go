@@ -0,0 +1,35 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrecentDirectories(t *testing.T) {
+	s := newTestStore(t)
+	now := time.Unix(1_700_000_000, 0)
+
+	// 常用但很久以前的目錄
+	for range 5 {
+		require.NoError(t, s.TouchDirectory("/src/old", now.Add(-30*24*time.Hour)))
+	}
+	// 最近用過兩次的目錄
+	require.NoError(t, s.TouchDirectory("/src/new", now.Add(-time.Minute)))
+	require.NoError(t, s.TouchDirectory("/src/new", now.Add(-time.Minute)))
+	require.NoError(t, s.TouchDirectory("/src/once", now.Add(-48*time.Hour)))
+
+	dirs, err := s.FrecentDirectories(now)
+	require.NoError(t, err)
+	require.Len(t, dirs, 3)
+	assert.Equal(t, "/src/new", dirs[0].Path)
+	assert.Equal(t, 2.0, dirs[0].Rank)
+	assert.Equal(t, "/src/old", dirs[1].Path)
+	assert.Equal(t, "/src/once", dirs[2].Path)
+
+	require.NoError(t, s.RemoveDirectory("/src/old"))
+	dirs, _ = s.FrecentDirectories(now)
+	assert.Len(t, dirs, 2)
+}
@@ -0,0 +1,197 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// statusEnded 是 pane 關閉時記錄的狀態，與 tmux.StatusEnded 的名稱一致。
+const statusEnded = "ended"
+
+type StatusEvent struct {
+	ID          int64
+	SessionName string
+	PaneID      string
+	OldStatus   string
+	NewStatus   string
+	Source      string // hook、title 或 content
+	At          time.Time
+}
+
+type StatusDuration struct {
+	SessionName string
+	PaneID      string
+	Status      string
+	Duration    time.Duration
+}
+
+func (s *Store) RecordStatusEvents(events []StatusEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO status_events (session_name, pane_id, old_status, new_status, source, at_ms)
+		VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("prepare insert: %w", err)
+	}
+	defer stmt.Close()
+	for _, e := range events {
+		if _, err := stmt.Exec(e.SessionName, e.PaneID, e.OldStatus, e.NewStatus, e.Source, e.At.UnixMilli()); err != nil {
+			return fmt.Errorf("insert status event: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Store) ListStatusEvents(sessionName string, since time.Time) ([]StatusEvent, error) {
+	rows, err := s.db.Query(`
+		SELECT id, session_name, pane_id, old_status, new_status, source, at_ms
+		FROM status_events WHERE session_name = ? AND at_ms >= ?
+		ORDER BY at_ms, id`,
+		sessionName, since.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []StatusEvent
+	for rows.Next() {
+		var e StatusEvent
+		var atMs int64
+		if err := rows.Scan(&e.ID, &e.SessionName, &e.PaneID, &e.OldStatus, &e.NewStatus, &e.Source, &atMs); err != nil {
+			return nil, err
+		}
+		e.At = time.UnixMilli(atMs)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// LastStatusEvent 回傳 pane 最近一筆事件，沒有紀錄時 ok 為 false。
+func (s *Store) LastStatusEvent(sessionName, paneID string) (e StatusEvent, ok bool, err error) {
+	var atMs int64
+	err = s.db.QueryRow(`
+		SELECT id, session_name, pane_id, old_status, new_status, source, at_ms
+		FROM status_events WHERE session_name = ? AND pane_id = ?
+		ORDER BY at_ms DESC, id DESC LIMIT 1`,
+		sessionName, paneID).Scan(&e.ID, &e.SessionName, &e.PaneID, &e.OldStatus, &e.NewStatus, &e.Source, &atMs)
+	if err == sql.ErrNoRows {
+		return StatusEvent{}, false, nil
+	}
+	if err != nil {
+		return StatusEvent{}, false, err
+	}
+	e.At = time.UnixMilli(atMs)
+	return e, true, nil
+}
+
+// StatusDurations 計算 [from, to) 期間每個 pane 處於各狀態的時間。
+// 期間開始時的狀態取自之前最後一筆事件；沒有更早的紀錄時以期間內第一筆事件的舊狀態為準。
+// 最後一筆事件之後的狀態視為持續到 to；轉換到 ended（pane 已關閉）之後的時間不計入。
+func (s *Store) StatusDurations(from, to time.Time) ([]StatusDuration, error) {
+	type paneKey struct{ session, pane string }
+	type timeline struct {
+		status string
+		cursor time.Time
+	}
+	totals := make(map[paneKey]map[string]time.Duration)
+	add := func(k paneKey, status string, d time.Duration) {
+		if status == "" || status == statusEnded || d <= 0 {
+			return
+		}
+		if totals[k] == nil {
+			totals[k] = make(map[string]time.Duration)
+		}
+		totals[k][status] += d
+	}
+
+	timelines := make(map[paneKey]*timeline)
+	rows, err := s.db.Query(`
+		SELECT session_name, pane_id, new_status, MAX(at_ms)
+		FROM status_events WHERE at_ms < ?
+		GROUP BY session_name, pane_id`,
+		from.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var k paneKey
+		var status string
+		var atMs int64
+		if err := rows.Scan(&k.session, &k.pane, &status, &atMs); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		timelines[k] = &timeline{status: status, cursor: from}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query(`
+		SELECT session_name, pane_id, old_status, new_status, at_ms
+		FROM status_events WHERE at_ms >= ? AND at_ms < ?
+		ORDER BY at_ms, id`,
+		from.UnixMilli(), to.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var k paneKey
+		var oldStatus, newStatus string
+		var atMs int64
+		if err := rows.Scan(&k.session, &k.pane, &oldStatus, &newStatus, &atMs); err != nil {
+			return nil, err
+		}
+		at := time.UnixMilli(atMs)
+		tl := timelines[k]
+		if tl == nil {
+			tl = &timeline{status: oldStatus, cursor: from}
+			timelines[k] = tl
+		}
+		add(k, tl.status, at.Sub(tl.cursor))
+		tl.status, tl.cursor = newStatus, at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for k, tl := range timelines {
+		add(k, tl.status, to.Sub(tl.cursor))
+	}
+
+	var result []StatusDuration
+	for k, byStatus := range totals {
+		for status, d := range byStatus {
+			result = append(result, StatusDuration{SessionName: k.session, PaneID: k.pane, Status: status, Duration: d})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.SessionName != b.SessionName {
+			return a.SessionName < b.SessionName
+		}
+		if a.PaneID != b.PaneID {
+			return a.PaneID < b.PaneID
+		}
+		return a.Status < b.Status
+	})
+	return result, nil
+}
+
+func (s *Store) PruneStatusEvents(before time.Time) (int64, error) {
+	res, err := s.db.Exec("DELETE FROM status_events WHERE at_ms < ?", before.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,103 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wake/tmux-session-menu/internal/store"
+)
+
+func TestStatusEvents_RecordAndList(t *testing.T) {
+	s := newTestStore(t)
+	t0 := time.UnixMilli(1_700_000_000_000)
+
+	require.NoError(t, s.RecordStatusEvents([]store.StatusEvent{
+		{SessionName: "work", PaneID: "%1", OldStatus: "idle", NewStatus: "running", Source: "hook", At: t0},
+		{SessionName: "work", PaneID: "%1", OldStatus: "running", NewStatus: "waiting", Source: "content", At: t0.Add(time.Minute)},
+		{SessionName: "other", PaneID: "%2", OldStatus: "idle", NewStatus: "running", Source: "title", At: t0},
+	}))
+	require.NoError(t, s.RecordStatusEvents(nil))
+
+	events, err := s.ListStatusEvents("work", t0)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "running", events[0].NewStatus)
+	assert.Equal(t, "hook", events[0].Source)
+	assert.Equal(t, t0, events[0].At)
+	assert.Equal(t, "waiting", events[1].NewStatus)
+
+	last, ok, err := s.LastStatusEvent("work", "%1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "waiting", last.NewStatus)
+
+	_, ok, err = s.LastStatusEvent("work", "%9")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestStatusEvents_Durations(t *testing.T) {
+	s := newTestStore(t)
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, s.RecordStatusEvents([]store.StatusEvent{
+		// 前一天開始執行，跨過午夜
+		{SessionName: "work", PaneID: "%1", OldStatus: "idle", NewStatus: "running", Source: "hook", At: day.Add(-time.Hour)},
+		{SessionName: "work", PaneID: "%1", OldStatus: "running", NewStatus: "waiting", Source: "hook", At: day.Add(30 * time.Minute)},
+		{SessionName: "work", PaneID: "%1", OldStatus: "waiting", NewStatus: "running", Source: "hook", At: day.Add(50 * time.Minute)},
+		{SessionName: "work", PaneID: "%1", OldStatus: "running", NewStatus: "idle", Source: "content", At: day.Add(time.Hour)},
+		// 沒有更早的紀錄，期間開始時的狀態取自第一筆事件的舊狀態
+		{SessionName: "api", PaneID: "%2", OldStatus: "waiting", NewStatus: "running", Source: "content", At: day.Add(10 * time.Minute)},
+	}))
+
+	durations, err := s.StatusDurations(day, day.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []store.StatusDuration{
+		{SessionName: "api", PaneID: "%2", Status: "running", Duration: 110 * time.Minute},
+		{SessionName: "api", PaneID: "%2", Status: "waiting", Duration: 10 * time.Minute},
+		{SessionName: "work", PaneID: "%1", Status: "idle", Duration: time.Hour},
+		{SessionName: "work", PaneID: "%1", Status: "running", Duration: 40 * time.Minute},
+		{SessionName: "work", PaneID: "%1", Status: "waiting", Duration: 20 * time.Minute},
+	}, durations)
+}
+
+func TestStatusEvents_DurationsPaneEnded(t *testing.T) {
+	s := newTestStore(t)
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, s.RecordStatusEvents([]store.StatusEvent{
+		{SessionName: "work", PaneID: "%1", OldStatus: "idle", NewStatus: "running", Source: "content", At: day.Add(10 * time.Minute)},
+		// pane 在執行中被關閉
+		{SessionName: "work", PaneID: "%1", OldStatus: "running", NewStatus: "ended", At: day.Add(30 * time.Minute)},
+		// 前一天就已關閉的 pane 不計入
+		{SessionName: "old", PaneID: "%2", OldStatus: "idle", NewStatus: "running", Source: "content", At: day.Add(-2 * time.Hour)},
+		{SessionName: "old", PaneID: "%2", OldStatus: "running", NewStatus: "ended", At: day.Add(-time.Hour)},
+	}))
+
+	durations, err := s.StatusDurations(day, day.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []store.StatusDuration{
+		{SessionName: "work", PaneID: "%1", Status: "idle", Duration: 10 * time.Minute},
+		{SessionName: "work", PaneID: "%1", Status: "running", Duration: 20 * time.Minute},
+	}, durations)
+}
+
+func TestStatusEvents_Prune(t *testing.T) {
+	s := newTestStore(t)
+	t0 := time.UnixMilli(1_700_000_000_000)
+	require.NoError(t, s.RecordStatusEvents([]store.StatusEvent{
+		{SessionName: "work", PaneID: "%1", OldStatus: "idle", NewStatus: "running", Source: "hook", At: t0},
+		{SessionName: "work", PaneID: "%1", OldStatus: "running", NewStatus: "idle", Source: "hook", At: t0.Add(48 * time.Hour)},
+	}))
+
+	n, err := s.PruneStatusEvents(t0.Add(24 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	events, err := s.ListStatusEvents("work", time.Time{})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "idle", events[0].NewStatus)
+}
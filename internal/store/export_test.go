@@ -0,0 +1,16 @@
+package store
+
+// 測試用：建立位於指定 schema 版本的資料庫。
+
+// LatestVersion 是目前的 schema 版本。
+var LatestVersion = len(migrations)
+
+// OpenAtVersion 開啟資料庫並只升級到 version。
+func OpenAtVersion(path string, version int) (*Store, error) {
+	return open(path, version)
+}
+
+// SchemaVersion 回傳資料庫目前的 user_version。
+func (s *Store) SchemaVersion() (int, error) {
+	return userVersion(s.db)
+}
@@ -0,0 +1,183 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrNewerSchema 表示資料庫由較新版本的 tsm 建立，目前的版本無法安全地讀寫。
+var ErrNewerSchema = errors.New("database schema is newer than this tsm")
+
+// migration 是一次 schema 變更。migrations 依序編號（第 i 個為版本 i+1），
+// 套用後將 PRAGMA user_version 設為其編號。已發布的 migration 不可修改，只能新增。
+type migration func(tx *sql.Tx) error
+
+// migrations 是所有 schema 版本。引入 user_version 之前的資料庫版本為 0，
+// 但可能已有任何一個舊版的表格，因此前幾個 migration 以 IF NOT EXISTS 寫成，可安全地重複套用。
+var migrations = []migration{
+	// 1: 群組與 session 排序
+	execMigration(`
+		CREATE TABLE IF NOT EXISTS groups (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			sort_order INTEGER NOT NULL DEFAULT 0,
+			collapsed INTEGER NOT NULL DEFAULT 0
+		);
+		CREATE TABLE IF NOT EXISTS session_meta (
+			session_name TEXT PRIMARY KEY,
+			group_id INTEGER NOT NULL DEFAULT 0,
+			sort_order INTEGER NOT NULL DEFAULT 0,
+			custom_name TEXT NOT NULL DEFAULT ''
+		);`),
+	// 2: 狀態歷史
+	execMigration(`
+		CREATE TABLE IF NOT EXISTS status_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			session_name TEXT NOT NULL,
+			pane_id TEXT NOT NULL DEFAULT '',
+			old_status TEXT NOT NULL,
+			new_status TEXT NOT NULL,
+			source TEXT NOT NULL,
+			at_ms INTEGER NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_status_events_at ON status_events (at_ms);
+		CREATE INDEX IF NOT EXISTS idx_status_events_session ON status_events (session_name, pane_id, at_ms);`),
+	// 3: 設定
+	execMigration(`
+		CREATE TABLE IF NOT EXISTS settings (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL
+		);`),
+	// 4: 常用目錄
+	execMigration(`
+		CREATE TABLE IF NOT EXISTS directories (
+			path TEXT PRIMARY KEY,
+			rank REAL NOT NULL DEFAULT 0,
+			last_used_ms INTEGER NOT NULL
+		);`),
+	// 5: session snapshot
+	execMigration(`
+		CREATE TABLE IF NOT EXISTS snapshot_sessions (
+			session_name TEXT PRIMARY KEY,
+			path TEXT NOT NULL DEFAULT '',
+			group_name TEXT NOT NULL DEFAULT '',
+			sort_order INTEGER NOT NULL DEFAULT 0,
+			custom_name TEXT NOT NULL DEFAULT '',
+			saved_ms INTEGER NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS snapshot_panes (
+			session_name TEXT NOT NULL,
+			window_index INTEGER NOT NULL,
+			window_name TEXT NOT NULL DEFAULT '',
+			layout TEXT NOT NULL DEFAULT '',
+			pane_index INTEGER NOT NULL,
+			dir TEXT NOT NULL DEFAULT '',
+			command TEXT NOT NULL DEFAULT '',
+			args TEXT NOT NULL DEFAULT '',
+			agent TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (session_name, window_index, pane_index)
+		);`),
+	// 6: session_meta 改以 tmux session id 識別
+	migrateSessionMetaID,
+	// 7: 各 session id 上次對應的名稱，用來將狀態歷史跟著重新命名
+	execMigration(`
+		CREATE TABLE IF NOT EXISTS session_names (
+			session_id TEXT PRIMARY KEY,
+			session_name TEXT NOT NULL
+		);`),
+}
+
+// execMigration 建立執行一段 SQL 的 migration。
+func execMigration(schema string) migration {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(schema)
+		return err
+	}
+}
+
+// migrate 依序套用尚未套用的 migration 直到 version，每個 migration 各自在一個交易中
+// 執行並更新 user_version，失敗時停在上一個版本。資料庫版本比 version 新時回傳 ErrNewerSchema。
+func (s *Store) migrate(version int) error {
+	current, err := userVersion(s.db)
+	if err != nil {
+		return err
+	}
+	if current > len(migrations) {
+		return fmt.Errorf("%w: database version %d, supported up to %d; upgrade tsm",
+			ErrNewerSchema, current, len(migrations))
+	}
+	for v := current; v < version; v++ {
+		if err := s.applyMigration(v); err != nil {
+			return fmt.Errorf("migration %d: %w", v+1, err)
+		}
+	}
+	return nil
+}
+
+// applyMigration 在交易中套用 migrations[v]，並將 user_version 設為 v+1。
+// 另一個程序已先完成這個 migration 時略過。
+func (s *Store) applyMigration(v int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	current, err := userVersion(tx)
+	if err != nil {
+		return err
+	}
+	if current > v {
+		return nil
+	}
+	if err := migrations[v](tx); err != nil {
+		return err
+	}
+	// PRAGMA 不接受參數綁定；v 是整數，直接格式化不會有注入問題
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", v+1)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// userVersion 讀取資料庫的 PRAGMA user_version。
+func userVersion(q interface {
+	QueryRow(query string, args ...any) *sql.Row
+}) (int, error) {
+	var v int
+	err := q.QueryRow("PRAGMA user_version").Scan(&v)
+	return v, err
+}
+
+// migrateSessionMetaID 將以 session_name 為主鍵的 session_meta 改為以 session id 識別。
+// 既有資料的 session_id 為 NULL，下一次 ReconcileSessions 時依名稱對應到執行中的 session。
+// 引入 user_version 之前已遷移過的資料庫不重複遷移。
+func migrateSessionMetaID(tx *sql.Tx) error {
+	var n int
+	if err := tx.QueryRow(
+		"SELECT COUNT(*) FROM pragma_table_info('session_meta') WHERE name = 'session_id'").Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	for _, stmt := range []string{
+		"ALTER TABLE session_meta RENAME TO session_meta_old",
+		`CREATE TABLE session_meta (
+			session_id TEXT UNIQUE,
+			session_name TEXT NOT NULL UNIQUE,
+			group_id INTEGER NOT NULL DEFAULT 0,
+			sort_order INTEGER NOT NULL DEFAULT 0,
+			custom_name TEXT NOT NULL DEFAULT ''
+		)`,
+		`INSERT INTO session_meta (session_name, group_id, sort_order, custom_name)
+			SELECT session_name, group_id, sort_order, custom_name FROM session_meta_old`,
+		"DROP TABLE session_meta_old",
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("migrate session_meta: %w", err)
+		}
+	}
+	return nil
+}
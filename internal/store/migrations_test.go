@@ -0,0 +1,174 @@
+package store_test
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wake/tmux-session-menu/internal/store"
+)
+
+// 各 schema 版本開始提供的功能。
+const (
+	versionGroups    = 1
+	versionEvents    = 2
+	versionSettings  = 3
+	versionDirs      = 4
+	versionSnapshots = 5
+)
+
+var seedAt = time.UnixMilli(1_700_000_000_000)
+
+// seedVersion 在位於 version 的資料庫寫入該版本已支援的資料。
+func seedVersion(t *testing.T, s *store.Store, version int) {
+	t.Helper()
+	if version >= versionGroups {
+		require.NoError(t, s.CreateGroup("work", 0))
+		require.NoError(t, s.SetSessionGroup("api", 1, 2))
+	}
+	if version >= versionEvents {
+		require.NoError(t, s.RecordStatusEvents([]store.StatusEvent{
+			{SessionName: "api", PaneID: "%1", OldStatus: "idle", NewStatus: "running", Source: "hook", At: seedAt},
+		}))
+	}
+	if version >= versionSettings {
+		require.NoError(t, s.SetSetting("theme", "dark"))
+	}
+	if version >= versionDirs {
+		require.NoError(t, s.TouchDirectory("/src/api", seedAt))
+	}
+	if version >= versionSnapshots {
+		require.NoError(t, s.SaveSnapshots([]store.Snapshot{{SessionName: "api", SavedAt: seedAt}}))
+	}
+}
+
+// rowsSince 回傳 seedVersion 在 since 版本開始寫入的資料筆數。
+func rowsSince(version, since int) int {
+	if version >= since {
+		return 1
+	}
+	return 0
+}
+
+// assertSeeded 確認升級後保留了 version 寫入的資料，且所有功能都可使用。
+func assertSeeded(t *testing.T, s *store.Store, version int) {
+	t.Helper()
+	metas, err := s.ListAllSessionMetas()
+	require.NoError(t, err)
+	if version >= versionGroups {
+		groups, err := s.ListGroups()
+		require.NoError(t, err)
+		require.Len(t, groups, 1)
+		assert.Equal(t, "work", groups[0].Name)
+		assert.Equal(t, []store.SessionMeta{{SessionName: "api", GroupID: 1, SortOrder: 2}}, metas)
+	} else {
+		assert.Empty(t, metas)
+	}
+
+	events, err := s.ListStatusEvents("api", time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, events, rowsSince(version, versionEvents))
+
+	theme, ok, err := s.Setting("theme")
+	require.NoError(t, err)
+	assert.Equal(t, version >= versionSettings, ok)
+	if ok {
+		assert.Equal(t, "dark", theme)
+	}
+
+	dirs, err := s.FrecentDirectories(seedAt)
+	require.NoError(t, err)
+	assert.Len(t, dirs, rowsSince(version, versionDirs))
+
+	snaps, err := s.ListSnapshots()
+	require.NoError(t, err)
+	assert.Len(t, snaps, rowsSince(version, versionSnapshots))
+
+	require.NoError(t, s.AssignSessionGroup("web", "home", 0))
+	require.NoError(t, s.ReconcileSessions("srv", []store.LiveSession{{ID: "$1", Name: "api"}, {ID: "$2", Name: "web"}}))
+	require.NoError(t, s.RecordStatusEvents([]store.StatusEvent{
+		{SessionName: "web", OldStatus: "idle", NewStatus: "waiting", Source: "title", At: seedAt},
+	}))
+	require.NoError(t, s.TouchDirectory("/src/web", seedAt))
+	require.NoError(t, s.SaveSnapshots([]store.Snapshot{{SessionName: "web", SavedAt: seedAt}}))
+}
+
+// TestOpen_UpgradesEveryVersion 從每一個歷史版本升級到最新版本。legacy 為引入
+// user_version 之前的資料庫：表格已存在，但版本為 0。
+func TestOpen_UpgradesEveryVersion(t *testing.T) {
+	for version := 0; version <= store.LatestVersion; version++ {
+		for _, legacy := range []bool{false, true} {
+			if legacy && version == 0 {
+				continue
+			}
+			t.Run(fmt.Sprintf("v%d/legacy=%t", version, legacy), func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), "tsm.db")
+				old, err := store.OpenAtVersion(path, version)
+				require.NoError(t, err)
+				got, err := old.SchemaVersion()
+				require.NoError(t, err)
+				require.Equal(t, version, got)
+				seedVersion(t, old, version)
+				require.NoError(t, old.Close())
+				if legacy {
+					setUserVersion(t, path, 0)
+				}
+
+				s, err := store.Open(path)
+				require.NoError(t, err)
+				t.Cleanup(func() { s.Close() })
+
+				got, err = s.SchemaVersion()
+				require.NoError(t, err)
+				assert.Equal(t, store.LatestVersion, got)
+				assertSeeded(t, s, version)
+			})
+		}
+	}
+}
+
+func TestOpen_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tsm.db")
+	s, err := store.Open(path)
+	require.NoError(t, err)
+	seedVersion(t, s, store.LatestVersion)
+	require.NoError(t, s.Close())
+
+	s, err = store.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	assertSeeded(t, s, store.LatestVersion)
+}
+
+func TestOpen_RefusesNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tsm.db")
+	s, err := store.Open(path)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+	setUserVersion(t, path, store.LatestVersion+1)
+
+	_, err = store.Open(path)
+	require.ErrorIs(t, err, store.ErrNewerSchema)
+
+	// 拒絕開啟時不應變更資料庫
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+	var version int
+	require.NoError(t, db.QueryRow("PRAGMA user_version").Scan(&version))
+	assert.Equal(t, store.LatestVersion+1, version)
+}
+
+// setUserVersion 直接修改資料庫的 user_version。
+func setUserVersion(t *testing.T, path string, version int) {
+	t.Helper()
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(fmt.Sprintf("PRAGMA user_version = %d", version))
+	require.NoError(t, err)
+}
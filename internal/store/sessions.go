@@ -0,0 +1,215 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// serverSettingKey 記錄上一次對應 session id 時的 tmux server，server 重新啟動後 id 會重新編號。
+const serverSettingKey = "tmux.server"
+
+// LiveSession 是目前執行中的 tmux session。
+type LiveSession struct {
+	ID   string // tmux session id，例如 "$3"
+	Name string
+}
+
+// metaRow 是 session_meta 的一列與其對應結果。
+type metaRow struct {
+	rowID   int64
+	id      string
+	name    string
+	matched bool
+}
+
+// ReconcileSessions 將 session_meta 對應到執行中的 session：
+//   - session id 相同但名稱不同時視為重新命名，更新名稱。
+//   - 其餘 session 依名稱認領尚未對應（剛遷移、以名稱寫入或 session 已結束）的資料列，記錄其 id。
+//   - 重新命名的 session 的狀態歷史一併改為新名稱。
+//
+// server 是 tmux server 的識別（pid 與啟動時間），與上次不同時先清除所有 id，
+// 避免新 server 重新編號的 id 對應到舊 session 的資料；空字串表示無法取得，不檢查。
+// 沒有對應到任何 session 的資料列保留不動，之後以相同名稱重建的 session 會再認領它。
+func (s *Store) ReconcileSessions(server string, live []LiveSession) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if server != "" {
+		var prev string
+		err := tx.QueryRow("SELECT value FROM settings WHERE key = ?", serverSettingKey).Scan(&prev)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if prev != server {
+			if _, err := tx.Exec("UPDATE session_meta SET session_id = NULL"); err != nil {
+				return fmt.Errorf("reset session ids: %w", err)
+			}
+			if _, err := tx.Exec("DELETE FROM session_names"); err != nil {
+				return fmt.Errorf("reset session names: %w", err)
+			}
+			if _, err := tx.Exec(`
+				INSERT INTO settings (key, value) VALUES (?, ?)
+				ON CONFLICT(key) DO UPDATE SET value = excluded.value`, serverSettingKey, server); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := renameStatusEvents(tx, live); err != nil {
+		return err
+	}
+
+	rows, err := loadMetaRows(tx)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]*metaRow)
+	byName := make(map[string]*metaRow)
+	for _, r := range rows {
+		if r.id != "" {
+			byID[r.id] = r
+		}
+		byName[r.name] = r
+	}
+
+	renames := make(map[*metaRow]string)
+	var unmatched []LiveSession
+	for _, l := range live {
+		r, ok := byID[l.ID]
+		if !ok {
+			unmatched = append(unmatched, l)
+			continue
+		}
+		r.matched = true
+		if r.name != l.Name {
+			renames[r] = l.Name
+		}
+	}
+	adopts := make(map[*metaRow]string)
+	for _, l := range unmatched {
+		if r, ok := byName[l.Name]; ok && !r.matched {
+			r.matched = true
+			adopts[r] = l.ID
+		}
+	}
+
+	for r, id := range adopts {
+		if _, err := tx.Exec("UPDATE session_meta SET session_id = ? WHERE rowid = ?", id, r.rowID); err != nil {
+			return fmt.Errorf("adopt session %s: %w", r.name, err)
+		}
+	}
+	// 名稱可能互換，先將要改名的資料列改為暫時的名稱，再改為新名稱；
+	// 新名稱被未對應的資料列佔用時，該資料列已過時，直接刪除。
+	for r := range renames {
+		if _, err := tx.Exec("UPDATE session_meta SET session_name = char(0) || rowid WHERE rowid = ?", r.rowID); err != nil {
+			return err
+		}
+	}
+	for r, name := range renames {
+		if stale, ok := byName[name]; ok && !stale.matched {
+			if _, err := tx.Exec("DELETE FROM session_meta WHERE rowid = ?", stale.rowID); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec("UPDATE session_meta SET session_name = ? WHERE rowid = ?", name, r.rowID); err != nil {
+			return fmt.Errorf("rename session %s to %s: %w", r.name, name, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// SessionNames 回傳上一次 ReconcileSessions 時各 session id 對應的名稱。
+func (s *Store) SessionNames() (map[string]string, error) {
+	rows, err := s.db.Query("SELECT session_id, session_name FROM session_names")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names := make(map[string]string)
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+	return names, rows.Err()
+}
+
+// renameStatusEvents 依 session_names 記錄的上次名稱找出重新命名的 session，
+// 將其狀態歷史改為新名稱，再記錄目前的對應。不論 session 是否有 session_meta 都會追蹤。
+func renameStatusEvents(tx *sql.Tx, live []LiveSession) error {
+	prev := make(map[string]string)
+	rows, err := tx.Query("SELECT session_id, session_name FROM session_names")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return err
+		}
+		prev[id] = name
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// 與 session_meta 相同，名稱可能互換，先改為暫時的名稱再改為新名稱
+	renamed := make(map[string]string)
+	for _, l := range live {
+		if old, ok := prev[l.ID]; ok && old != l.Name {
+			if _, err := tx.Exec("UPDATE status_events SET session_name = char(0) || ? WHERE session_name = ?", l.ID, old); err != nil {
+				return fmt.Errorf("rename status events of %s: %w", old, err)
+			}
+			renamed[l.ID] = l.Name
+		}
+	}
+	for id, name := range renamed {
+		if _, err := tx.Exec("UPDATE status_events SET session_name = ? WHERE session_name = char(0) || ?", name, id); err != nil {
+			return fmt.Errorf("rename status events to %s: %w", name, err)
+		}
+	}
+
+	// 只寫入有變動的對應，避免每次輪詢都改寫資料庫
+	for _, l := range live {
+		if old, ok := prev[l.ID]; ok && old == l.Name {
+			delete(prev, l.ID)
+			continue
+		}
+		delete(prev, l.ID)
+		if _, err := tx.Exec("INSERT OR REPLACE INTO session_names (session_id, session_name) VALUES (?, ?)", l.ID, l.Name); err != nil {
+			return err
+		}
+	}
+	for id := range prev {
+		if _, err := tx.Exec("DELETE FROM session_names WHERE session_id = ?", id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadMetaRows 讀取 session_meta 的識別欄位。
+func loadMetaRows(tx *sql.Tx) ([]*metaRow, error) {
+	rows, err := tx.Query("SELECT rowid, COALESCE(session_id, ''), session_name FROM session_meta")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*metaRow
+	for rows.Next() {
+		r := &metaRow{}
+		if err := rows.Scan(&r.rowID, &r.id, &r.name); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
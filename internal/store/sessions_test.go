@@ -0,0 +1,123 @@
+package store_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wake/tmux-session-menu/internal/store"
+)
+
+// metaNames 回傳 session id 對應的名稱（尚未對應的資料列以名稱為鍵、值為空字串）。
+func metaNames(t *testing.T, s *store.Store) map[string]string {
+	t.Helper()
+	metas, err := s.ListAllSessionMetas()
+	require.NoError(t, err)
+	out := make(map[string]string)
+	for _, m := range metas {
+		if m.SessionID == "" {
+			out[m.SessionName] = ""
+		} else {
+			out[m.SessionID] = m.SessionName
+		}
+	}
+	return out
+}
+
+func TestReconcileSessions(t *testing.T) {
+	s := newTestStore(t)
+	require.NoError(t, s.SetSessionGroup("api", 1, 0))
+	require.NoError(t, s.SetSessionGroup("web", 1, 1))
+	require.NoError(t, s.SetSessionGroup("gone", 1, 2))
+
+	// 依名稱認領
+	require.NoError(t, s.ReconcileSessions("srv1", []store.LiveSession{{ID: "$1", Name: "api"}, {ID: "$2", Name: "web"}}))
+	assert.Equal(t, map[string]string{"$1": "api", "$2": "web", "gone": ""}, metaNames(t, s))
+
+	// 重新命名與互換名稱都跟著 session id
+	require.NoError(t, s.ReconcileSessions("srv1", []store.LiveSession{{ID: "$1", Name: "web"}, {ID: "$2", Name: "api"}}))
+	assert.Equal(t, map[string]string{"$1": "web", "$2": "api", "gone": ""}, metaNames(t, s))
+	require.NoError(t, s.ReconcileSessions("srv1", []store.LiveSession{{ID: "$1", Name: "backend"}, {ID: "$2", Name: "api"}}))
+	assert.Equal(t, map[string]string{"$1": "backend", "$2": "api", "gone": ""}, metaNames(t, s))
+
+	// 改為未對應資料列的名稱時，舊資料列已過時
+	require.NoError(t, s.ReconcileSessions("srv1", []store.LiveSession{{ID: "$1", Name: "gone"}, {ID: "$2", Name: "api"}}))
+	assert.Equal(t, map[string]string{"$1": "gone", "$2": "api"}, metaNames(t, s))
+
+	metas, err := s.ListAllSessionMetas()
+	require.NoError(t, err)
+	assert.Equal(t, 0, metas[0].SortOrder, "renamed row keeps its own sort order")
+}
+
+func TestReconcileSessions_ServerRestart(t *testing.T) {
+	s := newTestStore(t)
+	require.NoError(t, s.SetSessionGroup("api", 1, 0))
+	require.NoError(t, s.ReconcileSessions("srv1", []store.LiveSession{{ID: "$1", Name: "api"}}))
+
+	// 新 server 的 $1 是另一個 session，不能繼承 api 的資料
+	require.NoError(t, s.ReconcileSessions("srv2", []store.LiveSession{{ID: "$1", Name: "scratch"}, {ID: "$2", Name: "api"}}))
+	assert.Equal(t, map[string]string{"$2": "api"}, metaNames(t, s))
+}
+
+func TestReconcileSessions_RenamesStatusEvents(t *testing.T) {
+	s := newTestStore(t)
+	record := func(session string) {
+		require.NoError(t, s.RecordStatusEvents([]store.StatusEvent{
+			{SessionName: session, PaneID: "%1", OldStatus: "idle", NewStatus: "running", Source: "hook", At: seedAt},
+		}))
+	}
+	count := func(session string) int {
+		events, err := s.ListStatusEvents(session, seedAt)
+		require.NoError(t, err)
+		return len(events)
+	}
+
+	// 沒有 session_meta 的 session 也會追蹤
+	require.NoError(t, s.ReconcileSessions("srv1", []store.LiveSession{{ID: "$1", Name: "api"}, {ID: "$2", Name: "web"}}))
+	record("api")
+	record("web")
+	record("web")
+
+	require.NoError(t, s.ReconcileSessions("srv1", []store.LiveSession{{ID: "$1", Name: "backend"}, {ID: "$2", Name: "web"}}))
+	assert.Equal(t, 0, count("api"))
+	assert.Equal(t, 1, count("backend"))
+
+	// 互換名稱
+	require.NoError(t, s.ReconcileSessions("srv1", []store.LiveSession{{ID: "$1", Name: "web"}, {ID: "$2", Name: "backend"}}))
+	assert.Equal(t, 1, count("web"))
+	assert.Equal(t, 2, count("backend"))
+
+	// 新 server 的 session id 重新編號，不可沿用舊的對應
+	require.NoError(t, s.ReconcileSessions("srv2", []store.LiveSession{{ID: "$1", Name: "scratch"}}))
+	assert.Equal(t, 1, count("web"))
+	assert.Equal(t, 0, count("scratch"))
+}
+
+func TestOpen_MigratesSessionMetaToID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	_, err = db.Exec(`
+		CREATE TABLE session_meta (
+			session_name TEXT PRIMARY KEY,
+			group_id INTEGER NOT NULL DEFAULT 0,
+			sort_order INTEGER NOT NULL DEFAULT 0,
+			custom_name TEXT NOT NULL DEFAULT ''
+		);
+		INSERT INTO session_meta VALUES ('api', 3, 1, '後端');`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	s, err := store.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	metas, err := s.ListAllSessionMetas()
+	require.NoError(t, err)
+	assert.Equal(t, []store.SessionMeta{{SessionName: "api", GroupID: 3, SortOrder: 1, CustomName: "後端"}}, metas)
+
+	require.NoError(t, s.ReconcileSessions("", []store.LiveSession{{ID: "$7", Name: "api"}}))
+	assert.Equal(t, map[string]string{"$7": "api"}, metaNames(t, s))
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// Snapshot 是 tsm snapshot save 記錄的一個 session，連同群組與排序資訊，
+// 讓 tmux server 重新啟動後可以重建。
+type Snapshot struct {
+	SessionName string
+	Path        string
+	GroupName   string // 空字串表示未分組
+	SortOrder   int
+	CustomName  string
+	SavedAt     time.Time
+	Panes       []SnapshotPane // 依 window index、pane index 排列
+}
+
+type SnapshotPane struct {
+	WindowIndex int
+	WindowName  string
+	Layout      string // window_layout，可直接交給 select-layout
+	PaneIndex   int
+	Dir         string
+	Command     string // pane_current_command
+	Args        string // 前景程序的指令列，各參數已加上 shell 引號（shell 或無法取得 argv 時為空字串）
+	Agent       string // 偵測到的 agent 名稱
+}
+
+// SaveSnapshots 以 snaps 取代先前儲存的所有 snapshot。
+func (s *Store) SaveSnapshots(snaps []Snapshot) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"snapshot_panes", "snapshot_sessions"} {
+		if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+			return fmt.Errorf("clear %s: %w", table, err)
+		}
+	}
+	for _, snap := range snaps {
+		if _, err := tx.Exec(`
+			INSERT INTO snapshot_sessions (session_name, path, group_name, sort_order, custom_name, saved_ms)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			snap.SessionName, snap.Path, snap.GroupName, snap.SortOrder, snap.CustomName, snap.SavedAt.UnixMilli()); err != nil {
+			return fmt.Errorf("insert snapshot %s: %w", snap.SessionName, err)
+		}
+		for _, p := range snap.Panes {
+			if _, err := tx.Exec(`
+				INSERT INTO snapshot_panes
+					(session_name, window_index, window_name, layout, pane_index, dir, command, args, agent)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				snap.SessionName, p.WindowIndex, p.WindowName, p.Layout, p.PaneIndex, p.Dir, p.Command, p.Args, p.Agent); err != nil {
+				return fmt.Errorf("insert snapshot pane %s:%d.%d: %w", snap.SessionName, p.WindowIndex, p.PaneIndex, err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Store) ListSnapshots() ([]Snapshot, error) {
+	rows, err := s.db.Query(`
+		SELECT session_name, path, group_name, sort_order, custom_name, saved_ms
+		FROM snapshot_sessions ORDER BY sort_order, session_name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var snaps []Snapshot
+	index := make(map[string]int)
+	for rows.Next() {
+		var snap Snapshot
+		var savedMs int64
+		if err := rows.Scan(&snap.SessionName, &snap.Path, &snap.GroupName, &snap.SortOrder, &snap.CustomName, &savedMs); err != nil {
+			return nil, err
+		}
+		snap.SavedAt = time.UnixMilli(savedMs)
+		index[snap.SessionName] = len(snaps)
+		snaps = append(snaps, snap)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	paneRows, err := s.db.Query(`
+		SELECT session_name, window_index, window_name, layout, pane_index, dir, command, args, agent
+		FROM snapshot_panes ORDER BY session_name, window_index, pane_index`)
+	if err != nil {
+		return nil, err
+	}
+	defer paneRows.Close()
+	for paneRows.Next() {
+		var name string
+		var p SnapshotPane
+		if err := paneRows.Scan(&name, &p.WindowIndex, &p.WindowName, &p.Layout, &p.PaneIndex, &p.Dir, &p.Command, &p.Args, &p.Agent); err != nil {
+			return nil, err
+		}
+		if i, ok := index[name]; ok {
+			snaps[i].Panes = append(snaps[i].Panes, p)
+		}
+	}
+	return snaps, paneRows.Err()
+}
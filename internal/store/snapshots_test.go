@@ -0,0 +1,34 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wake/tmux-session-menu/internal/store"
+)
+
+func TestSnapshots(t *testing.T) {
+	s := newTestStore(t)
+	saved := time.UnixMilli(1_700_000_000_000)
+
+	require.NoError(t, s.SaveSnapshots([]store.Snapshot{{SessionName: "stale", SavedAt: saved}}))
+
+	snaps := []store.Snapshot{
+		{
+			SessionName: "api", Path: "/src/api", GroupName: "dev", SortOrder: 1, CustomName: "後端", SavedAt: saved,
+			Panes: []store.SnapshotPane{
+				{WindowIndex: 0, WindowName: "code", Layout: "a1b2,80x24,0,0{40x24,0,0,1,39x24,41,0,2}", PaneIndex: 0, Dir: "/src/api", Command: "nvim", Args: "nvim ."},
+				{WindowIndex: 0, WindowName: "code", Layout: "a1b2,80x24,0,0{40x24,0,0,1,39x24,41,0,2}", PaneIndex: 1, Dir: "/src/api", Command: "claude", Args: "claude", Agent: "claude-code"},
+				{WindowIndex: 1, WindowName: "logs", PaneIndex: 0, Dir: "/var/log", Command: "zsh"},
+			},
+		},
+		{SessionName: "scratch", Path: "/tmp", SavedAt: saved, Panes: []store.SnapshotPane{{Dir: "/tmp", Command: "bash"}}},
+	}
+	require.NoError(t, s.SaveSnapshots(snaps))
+
+	got, err := s.ListSnapshots()
+	require.NoError(t, err)
+	assert.Equal(t, []store.Snapshot{snaps[1], snaps[0]}, got)
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 
 	_ "modernc.org/sqlite"
@@ -15,6 +16,7 @@ type Group struct {
 }
 
 type SessionMeta struct {
+	SessionID   string // tmux session id（例如 "$3"），尚未對應到執行中的 session 時為空字串
 	SessionName string
 	GroupID     int64
 	SortOrder   int
@@ -26,12 +28,17 @@ type Store struct {
 }
 
 func Open(path string) (*Store, error) {
+	return open(path, len(migrations))
+}
+
+// open 開啟資料庫並將 schema 升級到 version。
+func open(path string, version int) (*Store, error) {
 	db, err := sql.Open("sqlite", path+"?_pragma=journal_mode(wal)&_pragma=busy_timeout(5000)")
 	if err != nil {
 		return nil, fmt.Errorf("open db: %w", err)
 	}
 	s := &Store{db: db}
-	if err := s.migrate(); err != nil {
+	if err := s.migrate(version); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("migrate: %w", err)
 	}
@@ -40,24 +47,6 @@ func Open(path string) (*Store, error) {
 
 func (s *Store) Close() error { return s.db.Close() }
 
-func (s *Store) migrate() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS groups (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL,
-		sort_order INTEGER NOT NULL DEFAULT 0,
-		collapsed INTEGER NOT NULL DEFAULT 0
-	);
-	CREATE TABLE IF NOT EXISTS session_meta (
-		session_name TEXT PRIMARY KEY,
-		group_id INTEGER NOT NULL DEFAULT 0,
-		sort_order INTEGER NOT NULL DEFAULT 0,
-		custom_name TEXT NOT NULL DEFAULT ''
-	);`
-	_, err := s.db.Exec(schema)
-	return err
-}
-
 func (s *Store) CreateGroup(name string, sortOrder int) error {
 	_, err := s.db.Exec("INSERT INTO groups (name, sort_order) VALUES (?, ?)", name, sortOrder)
 	return err
@@ -85,6 +74,11 @@ func (s *Store) RenameGroup(id int64, name string) error {
 	return err
 }
 
+func (s *Store) SetGroupCollapsed(id int64, collapsed bool) error {
+	_, err := s.db.Exec("UPDATE groups SET collapsed = ? WHERE id = ?", collapsed, id)
+	return err
+}
+
 func (s *Store) DeleteGroup(id int64) error {
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -110,9 +104,48 @@ func (s *Store) SetSessionGroup(sessionName string, groupID int64, sortOrder int
 	return err
 }
 
+func (s *Store) AssignSessionGroup(sessionName, groupName string, sortOrder int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var groupID int64
+	err = tx.QueryRow("SELECT id FROM groups WHERE name = ? ORDER BY id LIMIT 1", groupName).Scan(&groupID)
+	if errors.Is(err, sql.ErrNoRows) {
+		res, err := tx.Exec(
+			"INSERT INTO groups (name, sort_order) VALUES (?, (SELECT COUNT(*) FROM groups))", groupName)
+		if err != nil {
+			return fmt.Errorf("create group: %w", err)
+		}
+		if groupID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO session_meta (session_name, group_id, sort_order)
+		VALUES (?, ?, ?)
+		ON CONFLICT(session_name) DO UPDATE SET group_id = excluded.group_id, sort_order = excluded.sort_order`,
+		sessionName, groupID, sortOrder); err != nil {
+		return fmt.Errorf("set session group: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (s *Store) SetSessionCustomName(sessionName, customName string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO session_meta (session_name, custom_name) VALUES (?, ?)
+		ON CONFLICT(session_name) DO UPDATE SET custom_name = excluded.custom_name`,
+		sessionName, customName)
+	return err
+}
+
 func (s *Store) ListSessionMetas(groupID int64) ([]SessionMeta, error) {
 	rows, err := s.db.Query(
-		"SELECT session_name, group_id, sort_order, custom_name FROM session_meta WHERE group_id = ? ORDER BY sort_order, session_name",
+		"SELECT COALESCE(session_id, ''), session_name, group_id, sort_order, custom_name FROM session_meta WHERE group_id = ? ORDER BY sort_order, session_name",
 		groupID)
 	if err != nil {
 		return nil, err
@@ -121,7 +154,25 @@ func (s *Store) ListSessionMetas(groupID int64) ([]SessionMeta, error) {
 	var metas []SessionMeta
 	for rows.Next() {
 		var m SessionMeta
-		if err := rows.Scan(&m.SessionName, &m.GroupID, &m.SortOrder, &m.CustomName); err != nil {
+		if err := rows.Scan(&m.SessionID, &m.SessionName, &m.GroupID, &m.SortOrder, &m.CustomName); err != nil {
+			return nil, err
+		}
+		metas = append(metas, m)
+	}
+	return metas, rows.Err()
+}
+
+func (s *Store) ListAllSessionMetas() ([]SessionMeta, error) {
+	rows, err := s.db.Query(
+		"SELECT COALESCE(session_id, ''), session_name, group_id, sort_order, custom_name FROM session_meta ORDER BY group_id, sort_order, session_name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var metas []SessionMeta
+	for rows.Next() {
+		var m SessionMeta
+		if err := rows.Scan(&m.SessionID, &m.SessionName, &m.GroupID, &m.SortOrder, &m.CustomName); err != nil {
 			return nil, err
 		}
 		metas = append(metas, m)
@@ -133,3 +184,22 @@ func (s *Store) SetGroupOrder(id int64, sortOrder int) error {
 	_, err := s.db.Exec("UPDATE groups SET sort_order = ? WHERE id = ?", sortOrder, id)
 	return err
 }
+
+func (s *Store) Setting(key string) (string, bool, error) {
+	var value string
+	err := s.db.QueryRow("SELECT value FROM settings WHERE key = ?", key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+func (s *Store) SetSetting(key, value string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO settings (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value`, key, value)
+	return err
+}
@@ -50,6 +50,18 @@ func TestGroup_CRUD(t *testing.T) {
 	assert.Len(t, groups, 1)
 }
 
+func TestGroup_SetCollapsed(t *testing.T) {
+	s := newTestStore(t)
+
+	require.NoError(t, s.CreateGroup("dev", 0))
+	groups, _ := s.ListGroups()
+	assert.False(t, groups[0].Collapsed)
+
+	require.NoError(t, s.SetGroupCollapsed(groups[0].ID, true))
+	groups, _ = s.ListGroups()
+	assert.True(t, groups[0].Collapsed)
+}
+
 func TestSessionMeta_AssignAndList(t *testing.T) {
 	s := newTestStore(t)
 
@@ -73,6 +85,44 @@ func TestSessionMeta_AssignAndList(t *testing.T) {
 	assert.Equal(t, "standalone", ungrouped[0].SessionName)
 }
 
+func TestSessionMeta_AssignByGroupName(t *testing.T) {
+	s := newTestStore(t)
+	require.NoError(t, s.CreateGroup("dev", 0))
+
+	require.NoError(t, s.AssignSessionGroup("api", "dev", 2))
+	require.NoError(t, s.AssignSessionGroup("web", "ops", 1))
+	require.NoError(t, s.SetSessionCustomName("web", "前端"))
+
+	groups, err := s.ListGroups()
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "ops", groups[1].Name)
+	assert.Equal(t, 1, groups[1].SortOrder)
+
+	metas, err := s.ListAllSessionMetas()
+	require.NoError(t, err)
+	require.Len(t, metas, 2)
+	assert.Equal(t, store.SessionMeta{SessionName: "api", GroupID: groups[0].ID, SortOrder: 2}, metas[0])
+	assert.Equal(t, store.SessionMeta{SessionName: "web", GroupID: groups[1].ID, SortOrder: 1, CustomName: "前端"}, metas[1])
+}
+
+func TestSessionMeta_ListAll(t *testing.T) {
+	s := newTestStore(t)
+
+	require.NoError(t, s.CreateGroup("dev", 0))
+	groups, _ := s.ListGroups()
+
+	require.NoError(t, s.SetSessionGroup("b", groups[0].ID, 0))
+	require.NoError(t, s.SetSessionGroup("a", 0, 0))
+
+	metas, err := s.ListAllSessionMetas()
+	require.NoError(t, err)
+	assert.Len(t, metas, 2)
+	assert.Equal(t, "a", metas[0].SessionName)
+	assert.Equal(t, "b", metas[1].SessionName)
+	assert.Equal(t, groups[0].ID, metas[1].GroupID)
+}
+
 func TestSessionMeta_Reorder(t *testing.T) {
 	s := newTestStore(t)
 
@@ -113,3 +163,18 @@ func TestGroup_Reorder(t *testing.T) {
 	assert.Equal(t, "first", groups[1].Name)
 	assert.Equal(t, "second", groups[2].Name)
 }
+
+func TestSettings(t *testing.T) {
+	s := newTestStore(t)
+
+	_, ok, err := s.Setting("layout.split_percent")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, s.SetSetting("layout.split_percent", "40"))
+	require.NoError(t, s.SetSetting("layout.split_percent", "45"))
+	value, ok, err := s.Setting("layout.split_percent")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "45", value)
+}
@@ -0,0 +1,410 @@
+package tmux
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// AgentInput 是判斷 pane 屬於哪個 agent 所需的資訊。Content 應已移除 ANSI 逸出序列。
+type AgentInput struct {
+	Command string // pane_current_command
+	Title   string // pane title
+	Content string // 終端內容
+}
+
+// Detector 描述一種 AI agent（或 shell）的辨識與狀態偵測規則。
+type Detector interface {
+	// Name 回傳 agent 名稱，例如 "claude-code"。
+	Name() string
+	// Match 判斷 pane 是否由此 agent 執行。
+	Match(in AgentInput) bool
+	// Status 根據終端內容判斷狀態（第三層偵測）。
+	Status(content string) SessionStatus
+	// Model 從終端內容擷取模型名稱，無法判斷時回傳空字串。
+	Model(content string) string
+}
+
+// WaitClassifier 是可進一步判斷等待原因的 Detector（選用介面）。
+type WaitClassifier interface {
+	// Wait 根據終端內容判斷等待原因，只在 Status 回傳 StatusWaiting 時呼叫。
+	Wait(content string) WaitInfo
+}
+
+// Registry 依註冊順序比對 Detector，第一個符合者勝出。
+type Registry struct {
+	mu        sync.RWMutex
+	detectors []Detector
+}
+
+// NewRegistry 建立包含指定 Detector 的 Registry。
+func NewRegistry(detectors ...Detector) *Registry {
+	return &Registry{detectors: detectors}
+}
+
+// Register 加入 Detector。與既有 Detector 同名時取代之（保留原本順序），否則排在最前面，
+// 讓後註冊的規則優先於內建規則。
+func (r *Registry) Register(d Detector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.detectors {
+		if existing.Name() == d.Name() {
+			r.detectors[i] = d
+			return
+		}
+	}
+	r.detectors = append([]Detector{d}, r.detectors...)
+}
+
+// Detectors 回傳目前註冊的所有 Detector。
+func (r *Registry) Detectors() []Detector {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Detector(nil), r.detectors...)
+}
+
+// Lookup 依名稱尋找 Detector。
+func (r *Registry) Lookup(name string) Detector {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, d := range r.detectors {
+		if d.Name() == name {
+			return d
+		}
+	}
+	return nil
+}
+
+// Detect 回傳第一個符合的 Detector，沒有符合者時回傳 nil。
+func (r *Registry) Detect(in AgentInput) Detector {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, d := range r.detectors {
+		if d.Match(in) {
+			return d
+		}
+	}
+	return nil
+}
+
+// DefaultRegistry 是預設的 Detector 註冊表，包含所有內建 agent。
+// Shell 只以指令名稱比對，排在最前面避免 shell 中的輸出被誤判；
+// Claude Code 的內容標記最寬鬆，排在最後。
+var DefaultRegistry = NewRegistry(
+	Shell,
+	CodexCLI,
+	GeminiCLI,
+	Aider,
+	ClaudeCode,
+)
+
+// DetectAgent 以 DefaultRegistry 判斷 pane 所屬的 agent，回傳 nil 表示無法判斷。
+func DetectAgent(in AgentInput) Detector {
+	return DefaultRegistry.Detect(in)
+}
+
+// Pattern 是一個比對樣式：一般字串以子字串比對，以 "re:" 開頭者視為正規表達式。
+type Pattern struct {
+	Literal string
+	Regexp  *regexp.Regexp
+}
+
+// regexpPrefix 是正規表達式樣式的前綴。
+const regexpPrefix = "re:"
+
+// ParsePattern 解析樣式字串。
+func ParsePattern(s string) (Pattern, error) {
+	if expr, ok := strings.CutPrefix(s, regexpPrefix); ok {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return Pattern{}, fmt.Errorf("invalid regexp %q: %w", expr, err)
+		}
+		return Pattern{Regexp: re}, nil
+	}
+	if s == "" {
+		return Pattern{}, errors.New("empty pattern")
+	}
+	return Pattern{Literal: s}, nil
+}
+
+// UnmarshalText 實作 encoding.TextUnmarshaler，讓樣式可直接由設定檔解碼。
+func (p *Pattern) UnmarshalText(text []byte) error {
+	parsed, err := ParsePattern(string(text))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// String 回傳樣式的原始寫法。
+func (p Pattern) String() string {
+	if p.Regexp != nil {
+		return regexpPrefix + p.Regexp.String()
+	}
+	return p.Literal
+}
+
+// Match 判斷 s 是否符合樣式。
+func (p Pattern) Match(s string) bool {
+	if p.Regexp != nil {
+		return p.Regexp.MatchString(s)
+	}
+	return p.Literal != "" && strings.Contains(s, p.Literal)
+}
+
+// literals 將字串轉為一般字串樣式，供內建規則使用。
+func literals(ss ...string) []Pattern {
+	ps := make([]Pattern, len(ss))
+	for i, s := range ss {
+		ps[i] = Pattern{Literal: s}
+	}
+	return ps
+}
+
+// matchAny 判斷 s 是否符合任一樣式。
+func matchAny(patterns []Pattern, s string) bool {
+	for _, p := range patterns {
+		if p.Match(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// PatternDetector 以樣式描述 agent，內建的 AI agent 與設定檔中的自訂規則都以此實作。
+type PatternDetector struct {
+	AgentName    string
+	Commands     []string       // pane_current_command 等於任一者即屬於此 agent
+	Markers      []Pattern      // 終端內容符合任一者即屬於此 agent
+	Busy         []Pattern      // 執行中指標
+	Waiting      []Pattern      // 等待輸入指標（無法細分原因者）
+	Permission   []Pattern      // 工具權限確認
+	Plan         []Pattern      // plan mode 計畫確認
+	Question     []Pattern      // Y/n 等問題
+	Error        []Pattern      // 錯誤指標
+	Prompts      []string       // 最後一行等於任一者視為等待輸入
+	TailLines    int            // 只檢查最後幾行內容（0 表示全部）
+	ModelPattern *regexp.Regexp // 模型名稱樣式（可為 nil）
+}
+
+// Name 實作 Detector。
+func (p *PatternDetector) Name() string { return p.AgentName }
+
+// Match 實作 Detector。
+func (p *PatternDetector) Match(in AgentInput) bool {
+	for _, c := range p.Commands {
+		if in.Command == c {
+			return true
+		}
+	}
+	return matchAny(p.Markers, in.Content)
+}
+
+// Status 實作 Detector。檢查順序：忙碌指標 → Braille 旋轉指標 → 錯誤指標 → 等待指標 → 提示字元。
+func (p *PatternDetector) Status(content string) SessionStatus {
+	content = tailLines(content, p.TailLines)
+	if content == "" {
+		return StatusIdle
+	}
+	if matchAny(p.Busy, content) || containsBraille(content) {
+		return StatusRunning
+	}
+	if matchAny(p.Error, content) {
+		return StatusError
+	}
+	if matchAny(p.Waiting, content) || matchAny(p.Permission, content) || matchAny(p.Plan, content) ||
+		matchAny(p.Question, content) || hasPrompt(content, p.Prompts) {
+		return StatusWaiting
+	}
+	return StatusIdle
+}
+
+// Wait 實作 WaitClassifier。由最後一行往上比對，最靠近輸入處的對話框優先；
+// 都不符合時，提示字元視為等待下一個指示，其他等待指標視為問題。
+func (p *PatternDetector) Wait(content string) WaitInfo {
+	content = tailLines(content, p.TailLines)
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		switch l := lines[i]; {
+		case matchAny(p.Plan, l):
+			return WaitInfo{Reason: ReasonPlan}
+		case matchAny(p.Permission, l):
+			tool, command := extractPermission(lines, i)
+			return WaitInfo{Reason: ReasonPermission, Tool: tool, Command: command}
+		case matchAny(p.Question, l):
+			return WaitInfo{Reason: ReasonQuestion}
+		}
+	}
+	if hasPrompt(content, p.Prompts) {
+		return WaitInfo{Reason: ReasonPrompt}
+	}
+	if matchAny(p.Waiting, content) {
+		return WaitInfo{Reason: ReasonQuestion}
+	}
+	return WaitInfo{}
+}
+
+// Model 實作 Detector。
+func (p *PatternDetector) Model(content string) string {
+	if p.ModelPattern == nil {
+		return ""
+	}
+	m := p.ModelPattern.FindStringSubmatch(content)
+	switch len(m) {
+	case 0:
+		return ""
+	case 1:
+		return m[0]
+	default:
+		return m[1]
+	}
+}
+
+// Merge 回傳以 o 擴充後的副本：清單欄位附加在原有規則之後，
+// TailLines 與 ModelPattern 在 o 有設定時覆寫。p 本身不會被修改。
+func (p *PatternDetector) Merge(o *PatternDetector) *PatternDetector {
+	merged := &PatternDetector{
+		AgentName:    p.AgentName,
+		Commands:     append(append([]string(nil), p.Commands...), o.Commands...),
+		Markers:      append(append([]Pattern(nil), p.Markers...), o.Markers...),
+		Busy:         append(append([]Pattern(nil), p.Busy...), o.Busy...),
+		Waiting:      append(append([]Pattern(nil), p.Waiting...), o.Waiting...),
+		Permission:   append(append([]Pattern(nil), p.Permission...), o.Permission...),
+		Plan:         append(append([]Pattern(nil), p.Plan...), o.Plan...),
+		Question:     append(append([]Pattern(nil), p.Question...), o.Question...),
+		Error:        append(append([]Pattern(nil), p.Error...), o.Error...),
+		Prompts:      append(append([]string(nil), p.Prompts...), o.Prompts...),
+		TailLines:    p.TailLines,
+		ModelPattern: p.ModelPattern,
+	}
+	if o.TailLines > 0 {
+		merged.TailLines = o.TailLines
+	}
+	if o.ModelPattern != nil {
+		merged.ModelPattern = o.ModelPattern
+	}
+	return merged
+}
+
+// tailLines 回傳內容的最後 n 行（忽略結尾空行），n <= 0 時回傳原內容。
+func tailLines(s string, n int) string {
+	if n <= 0 {
+		return s
+	}
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) <= n {
+		return s
+	}
+	return strings.Join(lines[len(lines)-n:], "\n")
+}
+
+// shellDetector 辨識一般 shell，shell 沒有 AI 狀態，永遠視為閒置。
+type shellDetector struct {
+	commands []string
+}
+
+func (s *shellDetector) Name() string { return "shell" }
+
+func (s *shellDetector) Match(in AgentInput) bool {
+	for _, c := range s.commands {
+		if in.Command == c {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *shellDetector) Status(string) SessionStatus { return StatusIdle }
+
+func (s *shellDetector) Model(string) string { return "" }
+
+// brailleChars 是 Braille 旋轉指標字元。
+var brailleChars = [...]rune{'⠋', '⠙', '⠹', '⠸', '⠼', '⠴', '⠦', '⠧', '⠇', '⠏'}
+
+// containsBraille 檢查是否包含 Braille 旋轉指標字元。
+func containsBraille(s string) bool {
+	for _, r := range s {
+		for _, br := range brailleChars {
+			if r == br {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasPrompt 檢查最後一行是否為提示字元。
+func hasPrompt(s string, prompts []string) bool {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	lastLine := strings.TrimSpace(lines[len(lines)-1])
+	for _, p := range prompts {
+		if lastLine == p {
+			return true
+		}
+	}
+	return false
+}
+
+// 內建 agent。
+var (
+	// ClaudeCode 是 Anthropic Claude Code。
+	ClaudeCode = &PatternDetector{
+		AgentName: "claude-code",
+		Commands:  []string{"claude"},
+		Markers:   literals("ctrl+c to interrupt", "esc to interrupt", "? for shortcuts", "No, and tell Claude", "Claude Code"),
+		Busy: literals(
+			"ctrl+c to interrupt", "esc to interrupt",
+			"* Clauding", "* Hullaballooing", "* Thinking", "* Pondering",
+		),
+		Permission: literals(
+			"Do you want to proceed?", "Do you want to make this edit", "Do you want to create",
+			"Yes, allow once", "No, and tell Claude", "and don't ask again",
+		),
+		Plan:         literals("Would you like to proceed?", "No, keep planning", "auto-accept edits"),
+		Question:     literals("Continue? (Y/n)", "(Y/n)", "(y/N)", "[Y/n]", "[y/N]"),
+		Error:        literals("API Error:"),
+		Prompts:      []string{">", "❯"},
+		ModelPattern: regexp.MustCompile(`claude-(?:sonnet|opus|haiku)-[\w.-]+`),
+	}
+
+	// CodexCLI 是 OpenAI Codex CLI。
+	CodexCLI = &PatternDetector{
+		AgentName:    "codex",
+		Commands:     []string{"codex"},
+		Markers:      literals("OpenAI Codex"),
+		Busy:         literals("Esc to interrupt", "esc to interrupt"),
+		Permission:   literals("Allow command?", "Would you like to run the following command?", "Would you like to make the following edits?"),
+		Waiting:      literals("Yes (y)"),
+		Prompts:      []string{"›", "▌"},
+		ModelPattern: regexp.MustCompile(`\b(gpt-[\w.-]+|o\d(?:-mini)?)\b`),
+	}
+
+	// GeminiCLI 是 Google Gemini CLI。
+	GeminiCLI = &PatternDetector{
+		AgentName:    "gemini",
+		Commands:     []string{"gemini"},
+		Markers:      literals("Gemini CLI", "(esc to cancel"),
+		Busy:         literals("(esc to cancel"),
+		Permission:   literals("Allow execution?", "Yes, allow once", "Apply this change?"),
+		Waiting:      literals("Waiting for user confirmation"),
+		Prompts:      []string{">", "│ >"},
+		ModelPattern: regexp.MustCompile(`gemini-[\d.]+-(?:pro|flash)[\w.-]*`),
+	}
+
+	// Aider 是 aider-chat。
+	Aider = &PatternDetector{
+		AgentName:    "aider",
+		Commands:     []string{"aider"},
+		Markers:      literals("Aider v"),
+		Busy:         literals("Waiting for ", "Thinking..."),
+		Question:     literals("(Y)es/(N)o"),
+		Prompts:      []string{">", "code>", "architect>", "ask>", "diff>"},
+		ModelPattern: regexp.MustCompile(`(?m)^(?:Main )?[Mm]odel: (\S+)`),
+	}
+
+	// Shell 是一般互動式 shell。
+	Shell Detector = &shellDetector{commands: []string{"bash", "zsh", "fish", "sh", "dash", "ksh", "tcsh", "nu", "pwsh"}}
+)
@@ -0,0 +1,151 @@
+package tmux_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+)
+
+func TestDetectAgent(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    tmux.AgentInput
+		expected string
+	}{
+		{"claude by command", tmux.AgentInput{Command: "claude"}, "claude-code"},
+		{"claude by content", tmux.AgentInput{Command: "node", Content: "✻ Thinking… (esc to interrupt)"}, "claude-code"},
+		{"codex by command", tmux.AgentInput{Command: "codex", Content: "Working (3s • esc to interrupt)"}, "codex"},
+		{"codex by banner", tmux.AgentInput{Command: "node", Content: ">_ OpenAI Codex (v0.40)"}, "codex"},
+		{"gemini by command", tmux.AgentInput{Command: "gemini"}, "gemini"},
+		{"gemini by content", tmux.AgentInput{Content: "⠏ Reading files (esc to cancel, 3s)"}, "gemini"},
+		{"aider by banner", tmux.AgentInput{Command: "python3", Content: "Aider v0.86.1\nMain model: gpt-4o"}, "aider"},
+		{"shell", tmux.AgentInput{Command: "zsh", Content: "esc to interrupt"}, "shell"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := tmux.DetectAgent(tt.input)
+			require.NotNil(t, d)
+			assert.Equal(t, tt.expected, d.Name())
+		})
+	}
+
+	assert.Nil(t, tmux.DetectAgent(tmux.AgentInput{Command: "vim", Content: "main.go"}))
+}
+
+func TestPatternDetector_Status(t *testing.T) {
+	tests := []struct {
+		name     string
+		detector tmux.Detector
+		content  string
+		expected tmux.SessionStatus
+	}{
+		{"codex busy", tmux.CodexCLI, "• Working (3s • esc to interrupt)", tmux.StatusRunning},
+		{"codex approval", tmux.CodexCLI, "Would you like to run the following command?\n  $ rm -rf build", tmux.StatusWaiting},
+		{"gemini busy", tmux.GeminiCLI, "Reading (esc to cancel, 2s)", tmux.StatusRunning},
+		{"gemini permission", tmux.GeminiCLI, "Allow execution?\n● 1. Yes, allow once", tmux.StatusWaiting},
+		{"aider confirm", tmux.Aider, "Add file to the chat? (Y)es/(N)o [Yes]:", tmux.StatusWaiting},
+		{"aider prompt", tmux.Aider, "Tokens: 2k sent\ncode>", tmux.StatusWaiting},
+		{"aider idle", tmux.Aider, "Applied edit to main.go", tmux.StatusIdle},
+		{"shell always idle", tmux.Shell, "⠋ npm install", tmux.StatusIdle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.detector.Status(tt.content))
+		})
+	}
+}
+
+func TestPatternDetector_Model(t *testing.T) {
+	assert.Equal(t, "claude-opus-4-6", tmux.ClaudeCode.Model("Model: claude-opus-4-6"))
+	assert.Equal(t, "gpt-5-codex", tmux.CodexCLI.Model("model: gpt-5-codex  /status"))
+	assert.Equal(t, "gemini-2.5-pro", tmux.GeminiCLI.Model("gemini-2.5-pro (98% context left)"))
+	assert.Equal(t, "gpt-4o", tmux.Aider.Model("Aider v0.86.1\nMain model: gpt-4o with diff edit format"))
+	assert.Equal(t, "", tmux.Shell.Model("claude-opus-4-6"))
+}
+
+func TestDetectPaneStatus_UsesAgentRules(t *testing.T) {
+	// shell 中出現 Claude 的忙碌字樣不應判為執行中
+	assert.Equal(t, tmux.StatusIdle, tmux.DetectPaneStatus(tmux.AgentInput{Command: "bash", Content: "grep 'esc to interrupt' log"}))
+	assert.Equal(t, tmux.StatusRunning, tmux.DetectPaneStatus(tmux.AgentInput{Command: "gemini", Content: "Thinking (esc to cancel, 1s)"}))
+}
+
+func TestDetectPaneStatus_UnknownAgent(t *testing.T) {
+	// 已知指令但不屬於任何 agent 時不套用 Claude Code 的規則
+	assert.Equal(t, tmux.StatusIdle, tmux.DetectPaneStatus(tmux.AgentInput{Command: "vim", Content: "select theme\n❯"}))
+	assert.Equal(t, tmux.StatusIdle, tmux.DetectPaneStatus(tmux.AgentInput{Command: "less", Content: "Overwrite? (Y/n)"}))
+	assert.Equal(t, tmux.StatusIdle, tmux.DetectPaneStatus(tmux.AgentInput{Command: "zsh", Content: "❯"}))
+	// 沒有指令資訊時沿用 Claude Code 的規則
+	assert.Equal(t, tmux.StatusWaiting, tmux.DetectPaneStatus(tmux.AgentInput{Content: "❯"}))
+}
+
+func TestResolveStatus_UsesPaneCommand(t *testing.T) {
+	input := tmux.StatusInput{PaneCommand: "aider", PaneContent: "Tokens: 1k sent\narchitect>"}
+	assert.Equal(t, tmux.StatusWaiting, tmux.ResolveStatus(input))
+}
+
+func TestRegistry_Register(t *testing.T) {
+	custom := &tmux.PatternDetector{AgentName: "my-agent", Commands: []string{"claude"}}
+	r := tmux.NewRegistry(tmux.ClaudeCode)
+
+	// 新的 Detector 優先於既有的
+	r.Register(custom)
+	assert.Equal(t, "my-agent", r.Detect(tmux.AgentInput{Command: "claude"}).Name())
+	assert.Len(t, r.Detectors(), 2)
+
+	// 同名時取代
+	replacement := &tmux.PatternDetector{AgentName: "claude-code", Commands: []string{"cc"}}
+	r.Register(replacement)
+	assert.Len(t, r.Detectors(), 2)
+	assert.Same(t, replacement, r.Lookup("claude-code"))
+	assert.Nil(t, r.Lookup("unknown"))
+}
+
+func TestParsePattern(t *testing.T) {
+	p, err := tmux.ParsePattern("esc to interrupt")
+	require.NoError(t, err)
+	assert.True(t, p.Match("· esc to interrupt"))
+	assert.False(t, p.Match("ESC TO INTERRUPT"))
+	assert.Equal(t, "esc to interrupt", p.String())
+
+	p, err = tmux.ParsePattern(`re:(?m)^\* \w+ing…`)
+	require.NoError(t, err)
+	assert.True(t, p.Match("done\n* Flibbertigibbeting… (2s)"))
+	assert.False(t, p.Match("  * Thinking"))
+	assert.Equal(t, `re:(?m)^\* \w+ing…`, p.String())
+
+	_, err = tmux.ParsePattern("re:[")
+	assert.Error(t, err)
+	_, err = tmux.ParsePattern("")
+	assert.Error(t, err)
+}
+
+func TestPatternDetector_ErrorAndTailLines(t *testing.T) {
+	d := &tmux.PatternDetector{
+		AgentName: "test",
+		Busy:      []tmux.Pattern{{Literal: "working"}},
+		Error:     []tmux.Pattern{{Literal: "FAILED"}},
+		Prompts:   []string{">"},
+		TailLines: 2,
+	}
+	assert.Equal(t, tmux.StatusError, d.Status("step 1\nFAILED\n>"))
+	// 超出檢查範圍的舊輸出不影響判斷
+	assert.Equal(t, tmux.StatusWaiting, d.Status("working\nstep 2\ndone\n>\n"))
+	assert.Equal(t, tmux.StatusRunning, d.Status("done\nworking"))
+}
+
+func TestPatternDetector_Merge(t *testing.T) {
+	extra := &tmux.PatternDetector{
+		Busy:      []tmux.Pattern{{Literal: "* Flibbertigibbeting"}},
+		TailLines: 5,
+	}
+	merged := tmux.ClaudeCode.Merge(extra)
+
+	assert.Equal(t, "claude-code", merged.Name())
+	assert.Equal(t, 5, merged.TailLines)
+	assert.Equal(t, tmux.StatusRunning, merged.Status("* Flibbertigibbeting…"))
+	assert.Equal(t, tmux.StatusRunning, merged.Status("esc to interrupt"))
+	assert.Equal(t, "claude-opus-4-6", merged.Model("claude-opus-4-6"))
+	assert.Len(t, tmux.ClaudeCode.Busy, len(merged.Busy)-1)
+}
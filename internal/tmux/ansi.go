@@ -0,0 +1,169 @@
+package tmux
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ansiState 是 ECMA-48 逸出序列剖析器的狀態。
+type ansiState int
+
+const (
+	stateGround   ansiState = iota // 一般文字
+	stateEscape                    // 收到 ESC
+	stateEscInter                  // ESC 之後的中間位元組（例如 ESC ( B）
+	stateCSI                       // 控制序列（ESC [ 或 C1 CSI）
+	stateOSC                       // 作業系統指令（ESC ]），以 BEL 或 ST 結束
+	stateString                    // DCS／SOS／PM／APC 字串，以 ST 結束
+)
+
+// 特殊控制字元。
+const (
+	runeBEL = 0x07
+	runeCAN = 0x18
+	runeSUB = 0x1a
+	runeESC = 0x1b
+	runeDEL = 0x7f
+	runeDCS = 0x90
+	runeSOS = 0x98
+	runeCSI = 0x9b
+	runeST  = 0x9c
+	runeOSC = 0x9d
+	runePM  = 0x9e
+	runeAPC = 0x9f
+)
+
+// StripANSI 移除字串中的 ANSI／VT 逸出序列，以單次掃描的狀態機實作（不使用正規表達式）。
+// 支援含中間位元組的 CSI、以 BEL 或 ST 結束的 OSC、DCS／SOS／PM／APC 字串、
+// 兩位元組的 ESC 序列（例如 ESC ( B、ESC 7），以及 8-bit C1 控制字元。
+// CAN／SUB 會中止序列；未結束的序列直接捨棄；不合法的 UTF-8 以 U+FFFD 取代，
+// 確保輸出不含 ESC 與 C1 控制字元。
+func StripANSI(s string) string {
+	if !needsStrip(s) {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	state := stateGround
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+
+		// 以下字元在任何狀態都有相同效果
+		switch {
+		case r == runeESC:
+			state = stateEscape
+			i += size
+			continue
+		case r == runeCAN || r == runeSUB:
+			state = stateGround
+			i += size
+			continue
+		case r >= 0x80 && r <= 0x9f:
+			state = c1State(r)
+			i += size
+			continue
+		}
+
+		switch state {
+		case stateGround:
+			if r == utf8.RuneError && size == 1 {
+				b.WriteRune(utf8.RuneError)
+			} else {
+				b.WriteString(s[i : i+size])
+			}
+
+		case stateEscape:
+			switch {
+			case r < 0x20:
+				b.WriteRune(r) // C0 控制字元照常執行
+			case r <= 0x2f:
+				state = stateEscInter
+			case r == '[':
+				state = stateCSI
+			case r == ']':
+				state = stateOSC
+			case r == 'P' || r == 'X' || r == '^' || r == '_':
+				state = stateString
+			case r < runeDEL:
+				state = stateGround // 兩位元組序列的結尾（含 ST 的 ESC \）
+			case r == runeDEL:
+			default:
+				state = stateGround
+				continue // 非 ASCII：中止序列並以一般文字重新處理
+			}
+
+		case stateEscInter:
+			switch {
+			case r < 0x20:
+				b.WriteRune(r)
+			case r <= 0x2f:
+			case r < runeDEL:
+				state = stateGround
+			case r == runeDEL:
+			default:
+				state = stateGround
+				continue
+			}
+
+		case stateCSI:
+			switch {
+			case r < 0x20:
+				b.WriteRune(r)
+			case r <= 0x3f:
+				// 參數與中間位元組
+			case r < runeDEL:
+				state = stateGround
+			case r == runeDEL:
+			default:
+				state = stateGround
+				continue
+			}
+
+		case stateOSC, stateString:
+			if r == runeBEL {
+				state = stateGround
+			}
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// c1State 回傳 8-bit C1 控制字元之後的狀態。
+func c1State(r rune) ansiState {
+	switch r {
+	case runeCSI:
+		return stateCSI
+	case runeOSC:
+		return stateOSC
+	case runeDCS, runeSOS, runePM, runeAPC:
+		return stateString
+	default:
+		return stateGround // 包含 ST 與其他 C1 控制字元
+	}
+}
+
+// needsStrip 判斷字串是否包含需要處理的字元：ESC、CAN／SUB、C1 控制字元或不合法的 UTF-8。
+// 純文字直接回傳原字串，避免額外配置。
+func needsStrip(s string) bool {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == runeESC || c == runeCAN || c == runeSUB:
+			return true
+		case c >= 0x80:
+			return !utf8.ValidString(s[i:]) || containsControl(s[i:])
+		}
+	}
+	return false
+}
+
+// containsControl 判斷字串是否包含 ESC、CAN／SUB 或 C1 控制字元。
+func containsControl(s string) bool {
+	for _, r := range s {
+		if r == runeESC || r == runeCAN || r == runeSUB || (r >= 0x80 && r <= 0x9f) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package tmux_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+)
+
+func TestStripANSI_Sequences(t *testing.T) {
+	tests := []struct {
+		name, input, expected string
+	}{
+		{"csi private mode", "\033[?25lhidden\033[?25h", "hidden"},
+		{"csi intermediate", "a\033[0 qb", "ab"},
+		{"csi colon params", "\033[38:2:255:0:0mred\033[m", "red"},
+		{"osc title bel", "\033]0;✳ Claude Code\007❯", "❯"},
+		{"osc title st", "\033]2;title\033\\>", ">"},
+		{"osc 8 hyperlink", "\033]8;;https://example.com\033\\link\033]8;;\033\\", "link"},
+		{"dcs passthrough", "\033Ptmux;\033\033[31m\033\\text", "text"},
+		{"apc", "\033_Gf=100;AAAA\033\\img", "img"},
+		{"pm and sos", "\033^private\033\\a\033Xsos\033\\b", "ab"},
+		{"charset", "\033(Bplain\033)0", "plain"},
+		{"two byte", "\0337save\0338\033=\033>\033c", "save"},
+		{"c1 csi", "\u009b31mred", "red"},
+		{"c1 osc with c1 st", "\u009d0;title\u009cok", "ok"},
+		{"can aborts", "\033[31\030x", "x"},
+		{"control kept inside csi", "\033[1\n;2mx", "\nx"},
+		{"unterminated", "text\033]0;never ends", "text"},
+		{"lone esc", "end\033", "end"},
+		{"non ascii aborts escape", "\033✻ Thinking", "✻ Thinking"},
+		{"invalid utf8", "a\xc2\033[0m\x9bb", "a��b"},
+		{"unicode untouched", "❯ 你好 ⠋", "❯ 你好 ⠋"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tmux.StripANSI(tt.input))
+		})
+	}
+}
+
+func TestDetectStatus_WithEscapes(t *testing.T) {
+	// OSC 標題與字集切換不應影響提示字元判斷
+	content := "Done.\n\033]0;✳ Claude Code\007\033(B\033[2m❯\033[0m"
+	assert.Equal(t, tmux.StatusWaiting, tmux.DetectStatus(content))
+}
+
+func FuzzStripANSI(f *testing.F) {
+	seeds := []string{
+		"", "plain", "\033[31mred\033[0m", "\033]8;;url\033\\x\033]8;;\007",
+		"\033P1$r\033\\", "\033(B", "\u009b1m", "\033[", "\033]", "\xc2\033\\\x9b",
+		"\030\032\033\033\033[?1049h",
+	}
+	for _, s := range seeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, in string) {
+		out := tmux.StripANSI(in)
+
+		if strings.ContainsRune(out, '\033') || strings.ContainsAny(out, "\030\032") {
+			t.Fatalf("escape byte leaked: %q -> %q", in, out)
+		}
+		if !utf8.ValidString(out) {
+			t.Fatalf("invalid utf-8 output: %q -> %q", in, out)
+		}
+		for _, r := range out {
+			if r >= 0x80 && r <= 0x9f {
+				t.Fatalf("C1 control leaked: %q -> %q", in, out)
+			}
+		}
+		if again := tmux.StripANSI(out); again != out {
+			t.Fatalf("not idempotent: %q -> %q -> %q", in, out, again)
+		}
+	})
+}
@@ -1,101 +1,76 @@
 package tmux
 
-import (
-	"fmt"
-	"strings"
-)
+// Detection 是第三層偵測的結果。
+type Detection struct {
+	Status SessionStatus
+	Wait   WaitInfo // 只在 Status 為 StatusWaiting 且 agent 支援 WaitClassifier 時有值
+}
 
-// StripANSI 移除字串中的 ANSI 逸出序列，使用 O(n) 單次掃描，不使用正規表達式。
-func StripANSI(s string) string {
-	var b strings.Builder
-	b.Grow(len(s))
-	i := 0
-	for i < len(s) {
-		if s[i] == '\033' {
-			i++
-			if i < len(s) && s[i] == '[' {
-				i++
-				for i < len(s) && !((s[i] >= 'A' && s[i] <= 'Z') || (s[i] >= 'a' && s[i] <= 'z')) {
-					i++
-				}
-				if i < len(s) {
-					i++
-				}
-			}
-			continue
-		}
-		b.WriteByte(s[i])
-		i++
-	}
-	return b.String()
+// Detect 根據終端內容偵測 session 狀態與等待原因（第三層偵測），不考慮 pane 指令。
+func Detect(content string) Detection {
+	return DetectPane(AgentInput{Content: content})
 }
 
-// busyIndicators 是表示 AI 正在忙碌的文字指標。
-var busyIndicators = []string{"ctrl+c to interrupt", "esc to interrupt"}
+// DetectPane 以 DefaultRegistry 判斷 pane 所屬的 agent，並以該 agent 的規則偵測狀態。
+// 無法判斷 agent 時的處理見 paneAgent。
+func DetectPane(in AgentInput) Detection {
+	if in.Content == "" {
+		return Detection{Status: StatusIdle}
+	}
+	in.Content = StripANSI(in.Content)
+	return detectWith(paneAgent(in), in.Content)
+}
 
-// brailleChars 是 Braille 旋轉指標字元。
-var brailleChars = [...]rune{'⠋', '⠙', '⠹', '⠸', '⠼', '⠴', '⠦', '⠧', '⠇', '⠏'}
+// screenContextLines 是以畫面模型偵測時，檢查游標上方的列數。
+// Claude Code 等 TUI 的忙碌指標與確認選單都緊鄰輸入框（游標所在處）。
+const screenContextLines = 12
 
-// waitingIndicators 是表示等待使用者輸入的文字指標。
-var waitingIndicators = []string{"Yes, allow once", "No, and tell Claude", "Continue? (Y/n)", "(Y/n)"}
+// DetectScreen 以畫面模型偵測狀態：以整個畫面判斷 agent，
+// 但只以游標附近的內容判斷狀態，避免歷史紀錄或畫面上方的舊輸出造成誤判。
+// in.Content 不為空時（例如含歷史紀錄的擷取內容）也用於判斷 agent。
+func DetectScreen(in AgentInput, scr *Screen) Detection {
+	in.Content = StripANSI(in.Content) + "\n" + scr.String()
+	return detectWith(paneAgent(in), scr.NearCursor(screenContextLines))
+}
 
-// DetectStatus 根據終端內容偵測 session 狀態（第三層偵測）。
+// DetectStatus 與 Detect 相同，只回傳狀態。
 func DetectStatus(content string) SessionStatus {
-	if content == "" {
-		return StatusIdle
-	}
-	clean := StripANSI(content)
-
-	// 檢查忙碌指標
-	for _, ind := range busyIndicators {
-		if strings.Contains(clean, ind) {
-			return StatusRunning
-		}
-	}
+	return Detect(content).Status
+}
 
-	// 檢查 Braille 旋轉指標
-	for _, r := range clean {
-		for _, br := range brailleChars {
-			if r == br {
-				return StatusRunning
-			}
-		}
-	}
+// DetectPaneStatus 與 DetectPane 相同，只回傳狀態。
+func DetectPaneStatus(in AgentInput) SessionStatus {
+	return DetectPane(in).Status
+}
 
-	// 檢查星號旋轉指標模式
-	if containsSpinnerPattern(clean) {
-		return StatusRunning
-	}
+// DetectScreenStatus 與 DetectScreen 相同，只回傳狀態。
+func DetectScreenStatus(in AgentInput, scr *Screen) SessionStatus {
+	return DetectScreen(in, scr).Status
+}
 
-	// 檢查等待指標
-	for _, ind := range waitingIndicators {
-		if strings.Contains(clean, ind) {
-			return StatusWaiting
-		}
+// paneAgent 回傳 pane 所屬 agent 的 Detector。無法判斷時，若不知道 pane 執行的指令
+// （只有終端內容）沿用 Claude Code 的規則；已知指令卻不屬於任何 agent（例如編輯器）
+// 時回傳 nil，避免畫面上的文字被誤判為執行中或等待中。
+func paneAgent(in AgentInput) Detector {
+	if d := DetectAgent(in); d != nil {
+		return d
 	}
-
-	// 檢查最後一行是否為提示字元
-	if hasPromptChar(clean) {
-		return StatusWaiting
+	if in.Command == "" {
+		return ClaudeCode
 	}
-
-	return StatusIdle
-}
-
-// containsSpinnerPattern 檢查是否包含星號旋轉指標模式。
-func containsSpinnerPattern(s string) bool {
-	return strings.Contains(s, "* Clauding") || strings.Contains(s, "* Hullaballooing") ||
-		strings.Contains(s, "* Thinking") || strings.Contains(s, "* Pondering")
+	return nil
 }
 
-// hasPromptChar 檢查最後一行是否為提示字元。
-func hasPromptChar(s string) bool {
-	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
-	if len(lines) == 0 {
-		return false
+// detectWith 以 d 偵測 content 的狀態，等待中時再判斷原因。d 為 nil 時視為閒置。
+func detectWith(d Detector, content string) Detection {
+	if d == nil {
+		return Detection{Status: StatusIdle}
+	}
+	det := Detection{Status: d.Status(content)}
+	if c, ok := d.(WaitClassifier); ok && det.Status == StatusWaiting {
+		det.Wait = c.Wait(content)
 	}
-	lastLine := strings.TrimSpace(lines[len(lines)-1])
-	return lastLine == ">" || lastLine == "❯"
+	return det
 }
 
 // TitleStatus 表示從 pane title 偵測到的狀態。
@@ -119,27 +94,3 @@ func DetectTitleStatus(title string) TitleStatus {
 	}
 	return TitleUnknown
 }
-
-// PaneTitleFormat 是傳給 tmux list-panes -F 的格式字串，用於取得 pane title。
-const PaneTitleFormat = "#{session_name}:#{pane_title}"
-
-// ParseListPaneTitles 解析 tmux list-panes 的輸出，回傳 session name → pane title 的對應。
-func ParseListPaneTitles(output string) (map[string]string, error) {
-	result := make(map[string]string)
-	output = strings.TrimSpace(output)
-	if output == "" {
-		return result, nil
-	}
-	for _, line := range strings.Split(output, "\n") {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-		idx := strings.Index(line, ":")
-		if idx < 0 {
-			return nil, fmt.Errorf("unexpected format: %q", line)
-		}
-		result[line[:idx]] = line[idx+1:]
-	}
-	return result, nil
-}
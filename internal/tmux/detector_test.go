@@ -84,16 +84,3 @@ func TestDetectTitleStatus(t *testing.T) {
 		})
 	}
 }
-
-func TestParseListPaneTitles(t *testing.T) {
-	output := `my-project:⠋ Working
-api-server:✓ Done
-frontend:bash`
-
-	titles, err := tmux.ParseListPaneTitles(output)
-	assert.NoError(t, err)
-	assert.Len(t, titles, 3)
-	assert.Equal(t, "⠋ Working", titles["my-project"])
-	assert.Equal(t, "✓ Done", titles["api-server"])
-	assert.Equal(t, "bash", titles["frontend"])
-}
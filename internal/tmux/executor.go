@@ -15,7 +15,13 @@ func NewRealExecutor() *RealExecutor {
 
 // Execute 執行 tmux 指令並回傳輸出結果。
 func (e *RealExecutor) Execute(args ...string) (string, error) {
-	cmd := exec.Command("tmux", args...)
-	out, err := cmd.Output()
-	return strings.TrimSpace(string(out)), err
+	out, err := e.ExecuteRaw(args...)
+	return strings.TrimSpace(out), err
+}
+
+// ExecuteRaw 實作 RawExecutor，回傳未修剪的輸出。
+// 與 ControlClient 相同以 -u 執行，避免非 UTF-8 locale 下輸出中的 tab 與換行被改為 _。
+func (e *RealExecutor) ExecuteRaw(args ...string) (string, error) {
+	out, err := exec.Command("tmux", append([]string{"-u"}, args...)...).Output()
+	return string(out), err
 }
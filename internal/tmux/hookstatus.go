@@ -17,6 +17,18 @@ type HookStatus struct {
 	RawStatus string        `json:"status"`
 	Timestamp int64         `json:"timestamp"`
 	Event     string        `json:"event"`
+	Pane      string        `json:"pane,omitempty"`    // 寫入狀態的 pane id（$TMUX_PANE）
+	Reason    WaitReason    `json:"reason,omitempty"`  // 等待原因
+	Tool      string        `json:"tool,omitempty"`    // 請求權限的工具
+	Command   string        `json:"command,omitempty"` // 請求權限的指令或檔案
+}
+
+// WaitInfo 回傳 hook 記錄的等待原因，不是等待狀態時回傳零值。
+func (h HookStatus) WaitInfo() WaitInfo {
+	if h.Status != StatusWaiting {
+		return WaitInfo{}
+	}
+	return WaitInfo{Reason: h.Reason, Tool: h.Tool, Command: h.Command}
 }
 
 // IsValid 檢查 hook 狀態是否仍在有效期限內。
@@ -24,9 +36,15 @@ func (h HookStatus) IsValid() bool {
 	return time.Since(time.Unix(h.Timestamp, 0)) < hookStatusTTL
 }
 
+// AppliesTo 回傳此狀態是否屬於指定 pane。未記錄 pane 的狀態視為屬於所有 pane。
+func (h HookStatus) AppliesTo(paneID string) bool {
+	return h.Pane == "" || h.Pane == paneID
+}
+
 // ReadHookStatus 從狀態目錄讀取指定 session 的 hook 狀態檔案。
-func ReadHookStatus(statusDir, sessionName string) (HookStatus, error) {
-	path := filepath.Join(statusDir, sessionName)
+// 狀態檔案以 session id 命名，session 重新命名後仍對應到同一個檔案。
+func ReadHookStatus(statusDir, sessionID string) (HookStatus, error) {
+	path := filepath.Join(statusDir, sessionID)
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return HookStatus{}, fmt.Errorf("read hook status: %w", err)
@@ -50,3 +68,65 @@ func ReadHookStatus(statusDir, sessionName string) (HookStatus, error) {
 
 	return hs, nil
 }
+
+// HookEventStatus 將 Claude Code hook 事件對應到 session 狀態。
+func HookEventStatus(event string) SessionStatus {
+	switch event {
+	case "UserPromptSubmit", "PreToolUse", "PostToolUse":
+		return StatusRunning
+	case "Stop", "PermissionRequest", "Notification":
+		return StatusWaiting
+	case "StopFailure":
+		return StatusError
+	default: // SessionStart、SessionEnd 及未知事件
+		return StatusIdle
+	}
+}
+
+// NewHookStatus 以目前時間建立指定事件的 HookStatus。等待原因只依事件判斷，
+// 呼叫端可再以 payload 內容（HookEventWait）覆寫。
+func NewHookStatus(event string) HookStatus {
+	status := HookEventStatus(event)
+	return HookStatus{
+		Status:    status,
+		RawStatus: status.String(),
+		Timestamp: time.Now().Unix(),
+		Event:     event,
+		Reason:    HookEventWait(event, "", "", ""),
+	}
+}
+
+// WriteHookStatus 將 hook 狀態寫入狀態目錄。先寫入暫存檔再 rename，
+// 讓 ReadHookStatus 永遠不會讀到寫到一半的 JSON。
+func WriteHookStatus(statusDir, sessionID string, hs HookStatus) error {
+	if sessionID == "" {
+		return fmt.Errorf("write hook status: empty session id")
+	}
+	if hs.RawStatus == "" {
+		hs.RawStatus = hs.Status.String()
+	}
+	data, err := json.Marshal(hs)
+	if err != nil {
+		return fmt.Errorf("encode hook status: %w", err)
+	}
+	if err := os.MkdirAll(statusDir, 0755); err != nil {
+		return fmt.Errorf("create status dir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(statusDir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write hook status: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write hook status: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(statusDir, sessionID)); err != nil {
+		return fmt.Errorf("rename hook status: %w", err)
+	}
+	return nil
+}
@@ -50,3 +50,65 @@ func TestReadHookStatus_NotFound(t *testing.T) {
 	_, err := tmux.ReadHookStatus(dir, "nonexistent")
 	assert.Error(t, err)
 }
+
+func TestHookEventStatus(t *testing.T) {
+	tests := []struct {
+		event    string
+		expected tmux.SessionStatus
+	}{
+		{"SessionStart", tmux.StatusIdle},
+		{"UserPromptSubmit", tmux.StatusRunning},
+		{"Stop", tmux.StatusWaiting},
+		{"PermissionRequest", tmux.StatusWaiting},
+		{"Notification", tmux.StatusWaiting},
+		{"StopFailure", tmux.StatusError},
+		{"SessionEnd", tmux.StatusIdle},
+		{"Unknown", tmux.StatusIdle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.event, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tmux.HookEventStatus(tt.event))
+		})
+	}
+}
+
+func TestWriteHookStatus_RoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "status")
+
+	require.NoError(t, tmux.WriteHookStatus(dir, "my-session", tmux.NewHookStatus("PermissionRequest")))
+
+	hs, err := tmux.ReadHookStatus(dir, "my-session")
+	require.NoError(t, err)
+	assert.Equal(t, tmux.StatusWaiting, hs.Status)
+	assert.Equal(t, "waiting", hs.RawStatus)
+	assert.Equal(t, "PermissionRequest", hs.Event)
+	assert.Equal(t, tmux.WaitInfo{Reason: tmux.ReasonPermission}, hs.WaitInfo())
+	assert.True(t, hs.IsValid())
+
+	// 不應留下暫存檔
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestWriteHookStatus_Overwrite(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, tmux.WriteHookStatus(dir, "s", tmux.NewHookStatus("UserPromptSubmit")))
+	require.NoError(t, tmux.WriteHookStatus(dir, "s", tmux.NewHookStatus("Stop")))
+
+	hs, err := tmux.ReadHookStatus(dir, "s")
+	require.NoError(t, err)
+	assert.Equal(t, tmux.StatusWaiting, hs.Status)
+	assert.Equal(t, "Stop", hs.Event)
+}
+
+func TestWriteHookStatus_EmptySession(t *testing.T) {
+	assert.Error(t, tmux.WriteHookStatus(t.TempDir(), "", tmux.NewHookStatus("Stop")))
+}
+
+func TestHookStatus_AppliesTo(t *testing.T) {
+	assert.True(t, tmux.HookStatus{}.AppliesTo("%1"))
+	assert.True(t, tmux.HookStatus{Pane: "%1"}.AppliesTo("%1"))
+	assert.False(t, tmux.HookStatus{Pane: "%1"}.AppliesTo("%2"))
+}
@@ -0,0 +1,316 @@
+package tmux
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// ErrControlClosed 表示 control mode 連線已結束。
+var ErrControlClosed = errors.New("tmux control mode connection closed")
+
+// notificationBuffer 是通知 channel 的緩衝大小，滿了之後新通知會被丟棄。
+const notificationBuffer = 256
+
+// Notification 是 control mode 在指令區塊之外送出的非同步通知。
+type Notification struct {
+	Name string   // 通知名稱（不含 % 前綴），例如 "session-changed"
+	Args []string // 以空白分隔的參數；%output 只含 pane id
+	Data string   // %output 的已解碼輸出內容
+}
+
+// structuralPrefixes 是代表 session、window 或 pane 結構變更的通知名稱前綴。
+var structuralPrefixes = []string{
+	"session", // session-changed、session-renamed、session-window-changed、sessions-changed
+	"client-session-changed",
+	"window-",
+	"unlinked-window-",
+	"layout-change",
+}
+
+// Structural 回報通知是否代表 session、window 或 pane 的增減、改名與版面變更。
+// %output 等內容通知不算在內，內容變化交由定期輪詢處理。
+func (n Notification) Structural() bool {
+	for _, p := range structuralPrefixes {
+		if strings.HasPrefix(n.Name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseNotification 解析一行 control mode 通知（例如 "%window-add @3"）。
+func ParseNotification(line string) (Notification, bool) {
+	if !strings.HasPrefix(line, "%") || len(line) < 2 {
+		return Notification{}, false
+	}
+	name, rest, _ := strings.Cut(line[1:], " ")
+	n := Notification{Name: name}
+	if name == "output" {
+		pane, data, _ := strings.Cut(rest, " ")
+		n.Args = []string{pane}
+		n.Data = decodeControlOutput(data)
+		return n, true
+	}
+	if rest != "" {
+		n.Args = strings.Split(rest, " ")
+	}
+	return n, true
+}
+
+// decodeControlOutput 還原 %output 中以 \ooo 八進位跳脫的位元組。
+func decodeControlOutput(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) && isOctal(s[i+1]) && isOctal(s[i+2]) && isOctal(s[i+3]) {
+			b.WriteByte((s[i+1]-'0')<<6 | (s[i+2]-'0')<<3 | (s[i+3] - '0'))
+			i += 3
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func isOctal(c byte) bool { return c >= '0' && c <= '7' }
+
+// quoteCommand 將參數組成一行 tmux 指令，每個參數以單引號包住。
+// 單引號內無法表示換行，因此換行改以雙引號的 "\n" 串接。
+func quoteCommand(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		lines := strings.Split(arg, "\n")
+		for j, l := range lines {
+			lines[j] = "'" + strings.ReplaceAll(l, "'", `'\''`) + "'"
+		}
+		quoted[i] = strings.Join(lines, `"\n"`)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// controlResult 是單一指令的回應。
+type controlResult struct {
+	output string
+	err    error
+}
+
+// ControlClient 透過持久的 tmux -C 連線執行指令，實作 Executor 介面，
+// 避免每次輪詢都建立子程序。指令回應以 %begin/%end/%error 區塊框住，
+// tmux 依送出順序回應，因此以 FIFO 佇列對應呼叫者。
+// 連線結束後 Execute 回傳 ErrControlClosed；以 SetFallback 指定替代的 Executor 時改由它執行。
+//
+// 控制連線本身會 attach 到一個 session，tmux 會將它計入該 session 的 attached client。
+type ControlClient struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	mu       sync.Mutex
+	pending  []chan controlResult
+	closed   bool
+	fallback Executor // 連線結束後改用的 Executor，nil 表示直接回傳錯誤
+
+	events chan Notification
+	done   chan struct{}
+}
+
+// NewControlClient 啟動 tmux -C 並 attach 到最近使用的 session。
+// global 為額外的 tmux 全域參數（例如 "-L", "mysocket"）。
+// tmux server 沒有任何 session 時會回傳錯誤。
+//
+// 以 -u 啟動：在 tmux 外以非 UTF-8 locale 執行時，tmux 否則會將輸出中的 tab 與換行改為 _，
+// 破壞 list-panes 等以 tab 分隔的格式。
+func NewControlClient(global ...string) (*ControlClient, error) {
+	args := append(append([]string{}, global...), "-u", "-C", "attach-session", "-f", "ignore-size")
+	cmd := exec.Command("tmux", args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("control mode stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("control mode stdout: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start control mode: %w", err)
+	}
+
+	c := &ControlClient{
+		cmd:    cmd,
+		stdin:  stdin,
+		events: make(chan Notification, notificationBuffer),
+		done:   make(chan struct{}),
+	}
+	ready := make(chan error, 1)
+	go c.readLoop(stdout, ready)
+
+	if err := <-ready; err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Events 回傳非同步通知的 channel，連線結束時關閉。
+func (c *ControlClient) Events() <-chan Notification {
+	return c.events
+}
+
+// Done 回傳連線結束時關閉的 channel。
+func (c *ControlClient) Done() <-chan struct{} {
+	return c.done
+}
+
+// SetFallback 指定連線結束後執行指令的 Executor（例如 RealExecutor）。
+func (c *ControlClient) SetFallback(e Executor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fallback = e
+}
+
+// Execute 透過 control mode 連線執行 tmux 指令並回傳輸出結果。
+func (c *ControlClient) Execute(args ...string) (string, error) {
+	out, err := c.run(false, args)
+	return strings.TrimSpace(out), err
+}
+
+// ExecuteRaw 實作 RawExecutor，回傳未修剪的輸出（各行以 \n 連接）。
+func (c *ControlClient) ExecuteRaw(args ...string) (string, error) {
+	return c.run(true, args)
+}
+
+// run 送出指令並等待回應；連線結束後改由 fallback 執行。
+func (c *ControlClient) run(raw bool, args []string) (string, error) {
+	ch := make(chan controlResult, 1)
+
+	c.mu.Lock()
+	if c.closed {
+		fallback := c.fallback
+		c.mu.Unlock()
+		if fallback == nil {
+			return "", ErrControlClosed
+		}
+		if r, ok := fallback.(RawExecutor); ok && raw {
+			return r.ExecuteRaw(args...)
+		}
+		return fallback.Execute(args...)
+	}
+	c.pending = append(c.pending, ch)
+	_, err := io.WriteString(c.stdin, quoteCommand(args)+"\n")
+	c.mu.Unlock()
+	if err != nil {
+		// 寫入失敗代表連線已中斷，readLoop 結束時會回應佇列中的請求
+		return "", fmt.Errorf("write control command: %w", err)
+	}
+
+	res := <-ch
+	return res.output, res.err
+}
+
+// Close 關閉 control mode 連線（tmux 會將此 client detach）。
+func (c *ControlClient) Close() error {
+	c.stdin.Close()
+	<-c.done
+	return c.cmd.Wait()
+}
+
+// readLoop 讀取 tmux 輸出，分派指令回應與通知。
+// 第一個非 client 發出的區塊是 attach 本身的結果，透過 ready 回報。
+func (c *ControlClient) readLoop(r io.Reader, ready chan<- error) {
+	defer c.shutdown(ready)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var (
+		inBlock  bool
+		blockID  string // "<time> <number> <flags>"，用來比對結尾行
+		fromUs   bool
+		lines    []string
+		attached bool
+	)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if inBlock {
+			isEnd := line == "%end "+blockID
+			isErr := line == "%error "+blockID
+			if !isEnd && !isErr {
+				lines = append(lines, line)
+				continue
+			}
+			inBlock = false
+			output := strings.Join(lines, "\n")
+			var err error
+			if isErr {
+				err = fmt.Errorf("tmux: %s", strings.TrimSpace(output))
+			}
+			if fromUs {
+				c.respond(controlResult{output: output, err: err})
+			} else if !attached {
+				attached = true
+				ready <- err
+				if err != nil {
+					return
+				}
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "%begin "):
+			blockID = strings.TrimPrefix(line, "%begin ")
+			fields := strings.Fields(blockID)
+			fromUs = len(fields) == 3 && fields[2] == "1"
+			inBlock = true
+			lines = nil
+		case line == "%exit" || strings.HasPrefix(line, "%exit "):
+			return
+		default:
+			if n, ok := ParseNotification(line); ok {
+				select {
+				case c.events <- n:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// respond 將回應交給佇列最前面的呼叫者。
+func (c *ControlClient) respond(res controlResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.pending) == 0 {
+		return
+	}
+	ch := c.pending[0]
+	c.pending = c.pending[1:]
+	ch <- res
+}
+
+// shutdown 標記連線結束並回應所有等待中的呼叫者。
+func (c *ControlClient) shutdown(ready chan<- error) {
+	c.mu.Lock()
+	c.closed = true
+	for _, ch := range c.pending {
+		ch <- controlResult{err: ErrControlClosed}
+	}
+	c.pending = nil
+	c.mu.Unlock()
+
+	select {
+	case ready <- ErrControlClosed:
+	default:
+	}
+	close(c.events)
+	close(c.done)
+}
@@ -0,0 +1,193 @@
+package tmux_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+)
+
+func TestParseNotification(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want tmux.Notification
+	}{
+		{"session changed", "%session-changed $1 work", tmux.Notification{Name: "session-changed", Args: []string{"$1", "work"}}},
+		{"sessions changed", "%sessions-changed", tmux.Notification{Name: "sessions-changed"}},
+		{"window add", "%window-add @3", tmux.Notification{Name: "window-add", Args: []string{"@3"}}},
+		{"output", `%output %1 hi\015\012there\134`, tmux.Notification{Name: "output", Args: []string{"%1"}, Data: "hi\r\nthere\\"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, ok := tmux.ParseNotification(tt.line)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, n)
+		})
+	}
+
+	_, ok := tmux.ParseNotification("plain text")
+	assert.False(t, ok)
+}
+
+// startTestServer 在獨立 socket 上啟動 tmux server 與一個 session。
+func startTestServer(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not installed")
+	}
+	socket := fmt.Sprintf("tsm-test-%d", time.Now().UnixNano())
+	require.NoError(t, exec.Command("tmux", "-L", socket, "new-session", "-d", "-s", "main").Run())
+	t.Cleanup(func() { exec.Command("tmux", "-L", socket, "kill-server").Run() })
+	return socket
+}
+
+func TestControlClient_Execute(t *testing.T) {
+	socket := startTestServer(t)
+
+	c, err := tmux.NewControlClient("-L", socket)
+	require.NoError(t, err)
+	defer c.Close()
+
+	out, err := c.Execute("list-sessions", "-F", "#{session_name}")
+	require.NoError(t, err)
+	assert.Equal(t, "main", out)
+
+	// 參數中的引號、$ 與換行原樣傳給 tmux：寫入 user option 後以格式讀回
+	_, err = c.Execute("set-option", "-g", "@tsm-test", "it's \"quoted\" $HOME\nnext")
+	require.NoError(t, err)
+	out, err = c.Execute("list-sessions", "-F", "#{@tsm-test}")
+	require.NoError(t, err)
+	assert.Equal(t, "it's \"quoted\" $HOME\nnext", out)
+
+	_, err = c.Execute("no-such-command")
+	assert.Error(t, err)
+
+	// Manager 可直接使用 ControlClient
+	sessions, err := tmux.NewManager(c).ListSessions()
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+}
+
+func TestControlClient_NonUTF8Locale(t *testing.T) {
+	socket := startTestServer(t)
+	// 在 tmux 外以非 UTF-8 locale 執行時，tmux 預設會將輸出中的 tab 與換行改為 _
+	for _, k := range []string{"TMUX", "TMUX_PANE"} {
+		t.Setenv(k, "")
+		os.Unsetenv(k)
+	}
+	t.Setenv("LANG", "C")
+	t.Setenv("LC_ALL", "C")
+
+	c, err := tmux.NewControlClient("-L", socket)
+	require.NoError(t, err)
+	defer c.Close()
+
+	out, err := c.Execute("list-panes", "-a", "-F", "#{session_name}\t#{pane_index}")
+	require.NoError(t, err)
+	assert.Equal(t, "main\t0", out)
+
+	_, err = c.Execute("set-option", "-g", "@tsm-test", "a\nb")
+	require.NoError(t, err)
+	out, err = c.Execute("list-sessions", "-F", "#{@tsm-test}")
+	require.NoError(t, err)
+	assert.Equal(t, "a\nb", out)
+
+	// RealExecutor 也不受 locale 影響
+	out, err = tmux.NewRealExecutor().Execute("-L", socket, "list-panes", "-a", "-F", "#{session_name}\t#{pane_index}")
+	require.NoError(t, err)
+	assert.Equal(t, "main\t0", out)
+}
+
+func TestControlClient_CaptureScreen(t *testing.T) {
+	socket := startTestServer(t)
+	require.NoError(t, exec.Command("tmux", "-L", socket, "new-session", "-d", "-s", "blank", "-x", "20", "-y", "6",
+		"printf '\\n\\n\\nhello'; sleep 30").Run())
+
+	c, err := tmux.NewControlClient("-L", socket)
+	require.NoError(t, err)
+	defer c.Close()
+	mgr := tmux.NewManager(c)
+
+	var pane tmux.Pane
+	require.Eventually(t, func() bool {
+		panes, err := mgr.ListPanes()
+		require.NoError(t, err)
+		for _, p := range panes {
+			if p.SessionName == "blank" && p.CursorX == 5 {
+				pane = p
+				return true
+			}
+		}
+		return false
+	}, 3*time.Second, 50*time.Millisecond)
+
+	scr, _, err := mgr.CaptureScreen(pane, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", scr.Line(pane.CursorY))
+	assert.Equal(t, 3, pane.CursorY)
+}
+
+func TestControlClient_Closed(t *testing.T) {
+	socket := startTestServer(t)
+
+	c, err := tmux.NewControlClient("-L", socket)
+	require.NoError(t, err)
+	require.NoError(t, c.Close())
+
+	_, err = c.Execute("list-sessions")
+	assert.ErrorIs(t, err, tmux.ErrControlClosed)
+
+	fallback := &mockExecutor{outputs: map[string]string{"list-sessions": "main"}}
+	c.SetFallback(fallback)
+	out, err := c.Execute("list-sessions")
+	require.NoError(t, err)
+	assert.Equal(t, "main", out)
+}
+
+func TestNotification_Structural(t *testing.T) {
+	for _, name := range []string{"sessions-changed", "session-renamed", "session-window-changed",
+		"client-session-changed", "window-add", "window-close", "unlinked-window-add", "layout-change"} {
+		assert.True(t, tmux.Notification{Name: name}.Structural(), name)
+	}
+	for _, name := range []string{"output", "extended-output", "pause", "client-detached", "message"} {
+		assert.False(t, tmux.Notification{Name: name}.Structural(), name)
+	}
+}
+
+func TestControlClient_Events(t *testing.T) {
+	socket := startTestServer(t)
+
+	c, err := tmux.NewControlClient("-L", socket)
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.Execute("new-session", "-d", "-s", "other")
+	require.NoError(t, err)
+
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case n := <-c.Events():
+			if n.Name == "sessions-changed" {
+				return
+			}
+		case <-timeout:
+			t.Fatal("did not receive sessions-changed notification")
+		}
+	}
+}
+
+func TestControlClient_NoSessions(t *testing.T) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not installed")
+	}
+	socket := fmt.Sprintf("tsm-test-empty-%d", time.Now().UnixNano())
+	_, err := tmux.NewControlClient("-L", socket)
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package tmux
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version 是 tmux 的版本號（只比較主次版本）。
+type Version struct {
+	Major int
+	Minor int
+}
+
+// popupMinVersion 是支援 display-popup 的最低 tmux 版本。
+var popupMinVersion = Version{Major: 3, Minor: 2}
+
+// ParseVersion 解析 tmux 版本字串，例如 "3.3a"、"tmux 3.2"、"next-3.4"。
+func ParseVersion(s string) (Version, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "tmux ")
+	s = strings.TrimPrefix(s, "next-")
+
+	major, rest, ok := strings.Cut(s, ".")
+	if !ok {
+		return Version{}, fmt.Errorf("unexpected version: %q", s)
+	}
+	maj, err := strconv.Atoi(major)
+	if err != nil {
+		return Version{}, fmt.Errorf("unexpected version: %q", s)
+	}
+	end := 0
+	for end < len(rest) && rest[end] >= '0' && rest[end] <= '9' {
+		end++
+	}
+	minor, err := strconv.Atoi(rest[:end])
+	if err != nil {
+		return Version{}, fmt.Errorf("unexpected version: %q", s)
+	}
+	return Version{Major: maj, Minor: minor}, nil
+}
+
+// AtLeast 回傳版本是否大於或等於 other。
+func (v Version) AtLeast(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+	return v.Minor >= other.Minor
+}
+
+// SupportsPopup 回傳此版本是否支援 display-popup。
+func (v Version) SupportsPopup() bool {
+	return v.AtLeast(popupMinVersion)
+}
+
+// ServerVersion 查詢 tmux server 的版本。
+func (m *Manager) ServerVersion() (Version, error) {
+	out, err := m.exec.Execute("display-message", "-p", "#{version}")
+	if err != nil {
+		return Version{}, err
+	}
+	return ParseVersion(out)
+}
+
+// DisplayPopup 在目前的 client 上開啟 popup 執行 command，popup 關閉後才返回。
+func (m *Manager) DisplayPopup(width, height, command string) error {
+	_, err := m.exec.Execute("display-popup", "-E", "-w", width, "-h", height, command)
+	return err
+}
@@ -0,0 +1,55 @@
+package tmux_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected tmux.Version
+	}{
+		{"3.3a", tmux.Version{Major: 3, Minor: 3}},
+		{"tmux 3.2", tmux.Version{Major: 3, Minor: 2}},
+		{"next-3.5", tmux.Version{Major: 3, Minor: 5}},
+		{"2.9a", tmux.Version{Major: 2, Minor: 9}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			v, err := tmux.ParseVersion(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, v)
+		})
+	}
+
+	_, err := tmux.ParseVersion("master")
+	assert.Error(t, err)
+}
+
+func TestVersion_SupportsPopup(t *testing.T) {
+	assert.True(t, tmux.Version{Major: 3, Minor: 2}.SupportsPopup())
+	assert.True(t, tmux.Version{Major: 4, Minor: 0}.SupportsPopup())
+	assert.False(t, tmux.Version{Major: 3, Minor: 1}.SupportsPopup())
+	assert.False(t, tmux.Version{Major: 2, Minor: 9}.SupportsPopup())
+}
+
+func TestManager_ServerVersion(t *testing.T) {
+	mock := &mockExecutor{outputs: map[string]string{
+		"display-message -p #{version}": "3.3a",
+	}}
+
+	v, err := tmux.NewManager(mock).ServerVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, tmux.Version{Major: 3, Minor: 3}, v)
+}
+
+func TestManager_DisplayPopup(t *testing.T) {
+	rec := &recordingExecutor{}
+
+	err := tmux.NewManager(rec).DisplayPopup("80%", "60%", "tsm --inline")
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"display-popup", "-E", "-w", "80%", "-h", "60%", "tsm --inline"}}, rec.calls)
+}
@@ -0,0 +1,131 @@
+package tmux
+
+import (
+	"strings"
+)
+
+// WaitReason 說明 pane 處於 StatusWaiting 的原因。
+type WaitReason string
+
+const (
+	ReasonNone       WaitReason = ""           // 無法判斷或不是等待狀態
+	ReasonPermission WaitReason = "permission" // 工具權限確認
+	ReasonPlan       WaitReason = "plan"       // plan mode 計畫確認
+	ReasonQuestion   WaitReason = "question"   // Y/n 等問題
+	ReasonPrompt     WaitReason = "prompt"     // 回到輸入提示字元，等待下一個指示
+)
+
+// waitUrgency 是彙整多個等待原因時的優先順序，數字越大越需要處理。
+var waitUrgency = map[WaitReason]int{
+	ReasonNone:       0,
+	ReasonPrompt:     1,
+	ReasonQuestion:   2,
+	ReasonPlan:       3,
+	ReasonPermission: 4,
+}
+
+// Blocking 回傳此原因是否代表 agent 卡在等待人類決定（而不只是閒置在提示字元）。
+func (r WaitReason) Blocking() bool {
+	return waitUrgency[r] > waitUrgency[ReasonPrompt]
+}
+
+// WaitInfo 是等待狀態的結構化原因。權限確認時 Tool 與 Command 為請求的工具與指令（可能為空）。
+type WaitInfo struct {
+	Reason  WaitReason
+	Tool    string
+	Command string
+}
+
+// Summary 回傳簡短說明，例如 "permission: Bash rm -rf build"。
+func (w WaitInfo) Summary() string {
+	if w.Reason == ReasonNone {
+		return ""
+	}
+	detail := strings.TrimSpace(w.Tool + " " + w.Command)
+	if detail == "" {
+		return string(w.Reason)
+	}
+	return string(w.Reason) + ": " + detail
+}
+
+// MostUrgentWait 回傳最需要處理的等待原因。
+func MostUrgentWait(waits ...WaitInfo) WaitInfo {
+	var result WaitInfo
+	for _, w := range waits {
+		if waitUrgency[w.Reason] > waitUrgency[result.Reason] {
+			result = w
+		}
+	}
+	return result
+}
+
+// permissionScanLines 是從權限問題往上尋找對話框邊框的最大列數。
+const permissionScanLines = 20
+
+// extractPermission 從權限確認畫面擷取請求的工具與指令。lines 為畫面各列，
+// at 為符合權限樣式的列。支援兩種常見版面：
+//
+//   - 對話框（Claude Code、Gemini CLI）：邊框下第一列為工具，第二列為指令
+//   - 問題下方以 "$ " 開頭的指令（Codex CLI）
+func extractPermission(lines []string, at int) (tool, command string) {
+	for i := at; i >= 0 && i >= at-permissionScanLines; i-- {
+		if !strings.HasPrefix(strings.TrimSpace(lines[i]), "╭") {
+			continue
+		}
+		var inner []string
+		for _, l := range lines[i+1 : at] {
+			l = strings.TrimSpace(strings.Trim(strings.TrimSpace(l), "│"))
+			if l != "" {
+				inner = append(inner, l)
+			}
+		}
+		if len(inner) > 0 {
+			tool = inner[0]
+		}
+		if len(inner) > 1 {
+			command = inner[1]
+		}
+		return tool, command
+	}
+	for _, l := range lines[at+1:] {
+		if c, ok := strings.CutPrefix(strings.TrimSpace(l), "$ "); ok {
+			return "shell", strings.TrimSpace(c)
+		}
+	}
+	return "", ""
+}
+
+// HookEventWait 依 Claude Code hook 事件與 payload 判斷等待原因。
+// toolName 為 PermissionRequest／PreToolUse 的 tool_name，
+// notificationType 與 message 為 Notification 的欄位（舊版沒有 notification_type）。
+func HookEventWait(event, toolName, notificationType, message string) WaitReason {
+	switch event {
+	case "Stop":
+		return ReasonPrompt
+	case "PermissionRequest":
+		return toolWait(toolName)
+	case "Notification":
+		switch {
+		case notificationType == "permission_prompt" || strings.Contains(message, "permission"):
+			return ReasonPermission
+		case notificationType == "idle_prompt" || strings.Contains(message, "waiting for your input"):
+			return ReasonPrompt
+		case notificationType == "elicitation_dialog":
+			return ReasonQuestion
+		}
+		return ReasonPrompt
+	}
+	return ReasonNone
+}
+
+// toolWait 回傳請求某個工具的權限時代表的等待原因。
+func toolWait(toolName string) WaitReason {
+	switch toolName {
+	case "ExitPlanMode":
+		return ReasonPlan
+	case "AskUserQuestion":
+		return ReasonQuestion
+	default:
+		return ReasonPermission
+	}
+}
@@ -0,0 +1,101 @@
+package tmux_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+)
+
+func TestDetect_WaitReason(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    tmux.WaitInfo
+	}{
+		{"idle prompt", "Done.\n❯", tmux.WaitInfo{Reason: tmux.ReasonPrompt}},
+		{"question", "Overwrite config? (y/N)", tmux.WaitInfo{Reason: tmux.ReasonQuestion}},
+		{
+			"bash permission",
+			"╭──────────────────────────────╮\n" +
+				"│ Bash command                 │\n" +
+				"│                              │\n" +
+				"│   rm -rf build               │\n" +
+				"│   Remove build output        │\n" +
+				"│                              │\n" +
+				"│ Do you want to proceed?      │\n" +
+				"│ ❯ 1. Yes                     │\n" +
+				"│   2. No, and tell Claude what to do differently (esc) │\n" +
+				"╰──────────────────────────────╯",
+			tmux.WaitInfo{Reason: tmux.ReasonPermission, Tool: "Bash command", Command: "rm -rf build"},
+		},
+		{
+			"plan approval",
+			"Would you like to proceed?\n" +
+				"❯ 1. Yes, and auto-accept edits\n" +
+				"  2. Yes, and manually approve edits\n" +
+				"  3. No, keep planning",
+			tmux.WaitInfo{Reason: tmux.ReasonPlan},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			det := tmux.Detect(tt.content)
+			assert.Equal(t, tmux.StatusWaiting, det.Status)
+			assert.Equal(t, tt.want, det.Wait)
+		})
+	}
+}
+
+func TestDetectPane_CodexPermission(t *testing.T) {
+	det := tmux.DetectPane(tmux.AgentInput{
+		Command: "codex",
+		Content: "Would you like to run the following command?\n\n  $ npm test\n\n› 1. Yes, proceed",
+	})
+	assert.Equal(t, tmux.StatusWaiting, det.Status)
+	assert.Equal(t, tmux.WaitInfo{Reason: tmux.ReasonPermission, Tool: "shell", Command: "npm test"}, det.Wait)
+}
+
+func TestDetect_NoWaitWhenRunning(t *testing.T) {
+	det := tmux.Detect("✻ Thinking… (esc to interrupt)")
+	assert.Equal(t, tmux.StatusRunning, det.Status)
+	assert.Equal(t, tmux.WaitInfo{}, det.Wait)
+}
+
+func TestWaitInfo_Summary(t *testing.T) {
+	assert.Equal(t, "", tmux.WaitInfo{}.Summary())
+	assert.Equal(t, "prompt", tmux.WaitInfo{Reason: tmux.ReasonPrompt}.Summary())
+	assert.Equal(t, "permission: Bash npm test",
+		tmux.WaitInfo{Reason: tmux.ReasonPermission, Tool: "Bash", Command: "npm test"}.Summary())
+}
+
+func TestMostUrgentWait(t *testing.T) {
+	prompt := tmux.WaitInfo{Reason: tmux.ReasonPrompt}
+	perm := tmux.WaitInfo{Reason: tmux.ReasonPermission, Tool: "Edit"}
+	assert.Equal(t, perm, tmux.MostUrgentWait(prompt, perm, tmux.WaitInfo{Reason: tmux.ReasonQuestion}))
+	assert.Equal(t, tmux.WaitInfo{}, tmux.MostUrgentWait())
+	assert.True(t, tmux.ReasonPlan.Blocking())
+	assert.False(t, tmux.ReasonPrompt.Blocking())
+}
+
+func TestHookEventWait(t *testing.T) {
+	tests := []struct {
+		name                                   string
+		event, tool, notificationType, message string
+		want                                   tmux.WaitReason
+	}{
+		{"stop", "Stop", "", "", "", tmux.ReasonPrompt},
+		{"permission", "PermissionRequest", "Bash", "", "", tmux.ReasonPermission},
+		{"plan", "PermissionRequest", "ExitPlanMode", "", "", tmux.ReasonPlan},
+		{"ask user", "PermissionRequest", "AskUserQuestion", "", "", tmux.ReasonQuestion},
+		{"notification type", "Notification", "", "permission_prompt", "", tmux.ReasonPermission},
+		{"legacy message", "Notification", "", "", "Claude needs your permission to use Bash", tmux.ReasonPermission},
+		{"idle", "Notification", "", "idle_prompt", "Claude is waiting for your input", tmux.ReasonPrompt},
+		{"running", "UserPromptSubmit", "", "", "", tmux.ReasonNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tmux.HookEventWait(tt.event, tt.tool, tt.notificationType, tt.message))
+		})
+	}
+}
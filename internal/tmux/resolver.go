@@ -5,26 +5,117 @@ type StatusInput struct {
 	HookStatus  *HookStatus // 第一層：hook 狀態檔案（可為 nil）
 	PaneTitle   string      // 第二層：pane title
 	PaneContent string      // 第三層：終端內容
+	PaneCommand string      // pane 目前執行的指令，用於判斷 agent
+	Screen      *Screen     // 第三層：可見畫面模型（可為 nil，有值時優先於 PaneContent）
+}
+
+// StatusSource 表示狀態由哪一層偵測得出。
+type StatusSource string
+
+const (
+	SourceHook    StatusSource = "hook"    // 第一層：hook 狀態檔案
+	SourceTitle   StatusSource = "title"   // 第二層：pane title
+	SourceContent StatusSource = "content" // 第三層：終端內容
+)
+
+// Resolution 是三層偵測的結果。
+type Resolution struct {
+	Status SessionStatus
+	Source StatusSource
+	Wait   WaitInfo // 等待原因，只在 Status 為 StatusWaiting 時有值
 }
 
 // ResolveStatus 整合三層偵測，依優先順序回傳最終狀態。
 // 優先順序：Hook 狀態（若有效）→ Pane Title → 終端內容。
 func ResolveStatus(input StatusInput) SessionStatus {
+	return Resolve(input).Status
+}
+
+// Resolve 與 ResolveStatus 相同，另外回傳決定狀態的偵測層與等待原因。
+func Resolve(input StatusInput) Resolution {
 	// 第一層：Hook 狀態檔案（最高優先）
 	if input.HookStatus != nil && input.HookStatus.IsValid() {
-		return input.HookStatus.Status
+		return Resolution{Status: input.HookStatus.Status, Source: SourceHook, Wait: input.HookStatus.WaitInfo()}
 	}
 
 	// 第二層：Pane Title
 	titleStatus := DetectTitleStatus(input.PaneTitle)
 	switch titleStatus {
 	case TitleRunning:
-		return StatusRunning
+		return Resolution{Status: StatusRunning, Source: SourceTitle}
 	case TitleDone:
 		// Title 顯示完成，降級到第三層進一步判斷
-		return DetectStatus(input.PaneContent)
+		return detectContent(input)
 	}
 
 	// 第三層：終端內容
-	return DetectStatus(input.PaneContent)
+	return detectContent(input)
+}
+
+// detectContent 以 pane 指令、title 與內容進行第三層偵測，有畫面模型時只看游標附近的內容。
+func detectContent(input StatusInput) Resolution {
+	in := AgentInput{
+		Command: input.PaneCommand,
+		Title:   input.PaneTitle,
+		Content: input.PaneContent,
+	}
+	var det Detection
+	if input.Screen != nil {
+		det = DetectScreen(in, input.Screen)
+	} else {
+		det = DetectPane(in)
+	}
+	return Resolution{Status: det.Status, Source: SourceContent, Wait: det.Wait}
+}
+
+// statusPrecedence 是彙整多個 pane 狀態時的優先順序，數字越大越優先：
+// 等待人類輸入 > 錯誤 > 執行中 > 閒置。
+var statusPrecedence = map[SessionStatus]int{
+	StatusIdle:    0,
+	StatusRunning: 1,
+	StatusError:   2,
+	StatusWaiting: 3,
+}
+
+// RollupStatus 將多個 pane 或 window 的狀態彙整為單一狀態。
+func RollupStatus(statuses ...SessionStatus) SessionStatus {
+	result := StatusIdle
+	for _, s := range statuses {
+		if statusPrecedence[s] > statusPrecedence[result] {
+			result = s
+		}
+	}
+	return result
+}
+
+// WithHookStatus 回傳套用 hook 狀態後的 session 副本：符合的 pane 改用 hook 狀態，
+// 再重新彙整 window 與 session 狀態。沒有 pane 資訊時直接套用到 session。
+func (s Session) WithHookStatus(h HookStatus) Session {
+	if len(s.Windows) == 0 {
+		s.Status = h.Status
+		s.Wait = h.WaitInfo()
+		return s
+	}
+
+	windows := make([]Window, len(s.Windows))
+	s.Status = StatusIdle
+	s.Wait = WaitInfo{}
+	for i, w := range s.Windows {
+		panes := make([]Pane, len(w.Panes))
+		copy(panes, w.Panes)
+		w.Panes = panes
+		w.Status = StatusIdle
+		for j := range panes {
+			if h.AppliesTo(panes[j].ID) {
+				panes[j].Status = h.Status
+				panes[j].Wait = h.WaitInfo()
+			}
+			w.Status = RollupStatus(w.Status, panes[j].Status)
+			s.Wait = MostUrgentWait(s.Wait, panes[j].Wait)
+		}
+		windows[i] = w
+		s.Status = RollupStatus(s.Status, w.Status)
+	}
+	s.Windows = windows
+	return s
 }
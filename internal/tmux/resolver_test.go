@@ -64,3 +64,45 @@ func TestResolveStatus_ExpiredHook_Ignored(t *testing.T) {
 	}
 	assert.Equal(t, tmux.StatusWaiting, tmux.ResolveStatus(input))
 }
+
+func TestRollupStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []tmux.SessionStatus
+		expected tmux.SessionStatus
+	}{
+		{"empty", nil, tmux.StatusIdle},
+		{"running beats idle", []tmux.SessionStatus{tmux.StatusIdle, tmux.StatusRunning}, tmux.StatusRunning},
+		{"waiting beats running", []tmux.SessionStatus{tmux.StatusRunning, tmux.StatusWaiting, tmux.StatusIdle}, tmux.StatusWaiting},
+		{"error beats running", []tmux.SessionStatus{tmux.StatusRunning, tmux.StatusError}, tmux.StatusError},
+		{"waiting beats error", []tmux.SessionStatus{tmux.StatusError, tmux.StatusWaiting}, tmux.StatusWaiting},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tmux.RollupStatus(tt.statuses...))
+		})
+	}
+}
+
+func TestSession_WithHookStatus(t *testing.T) {
+	s := tmux.Session{Windows: []tmux.Window{
+		{Index: 0, Panes: []tmux.Pane{{ID: "%1", Status: tmux.StatusIdle}}, Status: tmux.StatusIdle},
+		{Index: 1, Panes: []tmux.Pane{{ID: "%2", Status: tmux.StatusIdle}}, Status: tmux.StatusIdle},
+	}}
+
+	updated := s.WithHookStatus(tmux.HookStatus{Status: tmux.StatusWaiting, Pane: "%2"})
+	assert.Equal(t, tmux.StatusWaiting, updated.Status)
+	assert.Equal(t, tmux.StatusIdle, updated.Windows[0].Status)
+	assert.Equal(t, tmux.StatusWaiting, updated.Windows[1].Status)
+
+	// 原本的 session 不受影響
+	assert.Equal(t, tmux.StatusIdle, s.Windows[1].Panes[0].Status)
+
+	perm := tmux.HookStatus{Status: tmux.StatusWaiting, Pane: "%1", Reason: tmux.ReasonPermission, Tool: "Bash", Command: "make"}
+	updated = updated.WithHookStatus(perm)
+	assert.Equal(t, perm.WaitInfo(), updated.Windows[0].Panes[0].Wait)
+	assert.Equal(t, perm.WaitInfo(), updated.Wait)
+
+	bare := tmux.Session{}.WithHookStatus(tmux.HookStatus{Status: tmux.StatusRunning})
+	assert.Equal(t, tmux.StatusRunning, bare.Status)
+}
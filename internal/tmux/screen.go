@@ -0,0 +1,767 @@
+package tmux
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Color 是儲存格的顏色：ColorDefault、0–255 的索引色，或以 RGBColor 建立的 24-bit 色彩。
+type Color int32
+
+// ColorDefault 表示終端預設顏色。
+const ColorDefault Color = -1
+
+// colorRGBFlag 標記 24-bit 色彩。
+const colorRGBFlag = 1 << 24
+
+// RGBColor 建立 24-bit 色彩。
+func RGBColor(r, g, b uint8) Color {
+	return Color(colorRGBFlag | int32(r)<<16 | int32(g)<<8 | int32(b))
+}
+
+// IsRGB 判斷是否為 24-bit 色彩。
+func (c Color) IsRGB() bool { return c >= 0 && c&colorRGBFlag != 0 }
+
+// RGB 回傳 24-bit 色彩的分量，非 RGB 色彩回傳 0。
+func (c Color) RGB() (r, g, b uint8) {
+	if !c.IsRGB() {
+		return 0, 0, 0
+	}
+	return uint8(c >> 16), uint8(c >> 8), uint8(c)
+}
+
+// Attr 是儲存格的顯示屬性（SGR）。
+type Attr struct {
+	Fg, Bg    Color
+	Bold      bool
+	Dim       bool
+	Italic    bool
+	Underline bool
+	Blink     bool
+	Reverse   bool
+	Hidden    bool
+	Strike    bool
+}
+
+// defaultAttr 是重設後的屬性。
+var defaultAttr = Attr{Fg: ColorDefault, Bg: ColorDefault}
+
+// Cell 是畫面上的一個儲存格。寬字元佔兩格，第二格的 Rune 為 0。
+type Cell struct {
+	Rune rune
+	Attr Attr
+}
+
+// BlankCell 是清除後的儲存格（預設屬性的空白），也用來判斷儲存格是否為空。
+var BlankCell = Cell{Rune: ' ', Attr: defaultAttr}
+
+// maxOSCLength 是 OSC 字串保留的最大長度，超過的部分捨棄。
+const maxOSCLength = 4096
+
+// tabWidth 是預設的 tab 停止間隔。
+const tabWidth = 8
+
+// Screen 是記憶體中的 VT 畫面模型，可接收 capture-pane -e 的輸出或 control mode 的 %output
+// 資料流，維護可見畫面的字元、屬性與游標位置。只處理判斷狀態所需的常見序列：
+// 游標移動、清除、捲動區域、插入刪除、SGR、替代畫面與 OSC 標題，其餘序列會被忽略。
+// Screen 不保留捲出畫面的內容，也不是並行安全的。
+type Screen struct {
+	width, height int
+	cells         [][]Cell
+	main          [][]Cell // 使用替代畫面時保存的主畫面
+	altActive     bool
+
+	row, col    int
+	wrapPending bool // 已寫到最後一欄，下一個字元才換行
+	attr        Attr
+	saved       savedCursor
+	top, bottom int // 捲動區域（含）
+
+	autowrap      bool
+	cursorVisible bool
+	newlineMode   bool // LF 同時回到行首（capture-pane 的輸出只以 \n 分隔）
+	title         string
+
+	// 剖析器狀態，跨 Write 呼叫保留
+	state   ansiState
+	private byte   // CSI 的私有前綴，例如 '?'
+	params  []byte // CSI 參數原始位元組
+	inter   []byte // 中間位元組
+	osc     []byte
+	partial []byte // 未完整的 UTF-8 位元組
+}
+
+// savedCursor 是 DECSC／DECRC 保存的游標狀態。
+type savedCursor struct {
+	row, col int
+	attr     Attr
+}
+
+// NewScreen 建立指定大小的空白畫面，寬高小於 1 時視為 1。
+func NewScreen(width, height int) *Screen {
+	s := &Screen{width: max(width, 1), height: max(height, 1)}
+	s.reset()
+	return s
+}
+
+// reset 將畫面回到初始狀態（RIS）。
+func (s *Screen) reset() {
+	s.cells = newGrid(s.width, s.height)
+	s.main = nil
+	s.altActive = false
+	s.row, s.col, s.wrapPending = 0, 0, false
+	s.attr = defaultAttr
+	s.saved = savedCursor{attr: defaultAttr}
+	s.top, s.bottom = 0, s.height-1
+	s.autowrap = true
+	s.cursorVisible = true
+	s.title = ""
+	s.state = stateGround
+}
+
+// newGrid 建立空白的儲存格陣列。
+func newGrid(width, height int) [][]Cell {
+	grid := make([][]Cell, height)
+	for i := range grid {
+		grid[i] = newRow(width)
+	}
+	return grid
+}
+
+// newRow 建立一列空白儲存格。
+func newRow(width int) []Cell {
+	row := make([]Cell, width)
+	for i := range row {
+		row[i] = BlankCell
+	}
+	return row
+}
+
+// LoadCapture 清除畫面後載入 tmux capture-pane -e 的輸出。capture 的每一行對應一列，
+// 超過畫面高度的前段（歷史紀錄）會捲出畫面。capture 不含游標位置，需另外以 SetCursor 設定。
+func (s *Screen) LoadCapture(captured string) {
+	s.reset()
+	s.newlineMode = true
+	defer func() { s.newlineMode = false }()
+	_, _ = s.Write([]byte(strings.TrimSuffix(captured, "\n")))
+}
+
+// Write 實作 io.Writer，將終端輸出送入畫面模型，永遠不會回傳錯誤。
+func (s *Screen) Write(p []byte) (int, error) {
+	data := p
+	if len(s.partial) > 0 {
+		data = append(s.partial, p...)
+		s.partial = nil
+	}
+	for i := 0; i < len(data); {
+		if !utf8.FullRune(data[i:]) {
+			s.partial = append([]byte(nil), data[i:]...)
+			break
+		}
+		r, size := utf8.DecodeRune(data[i:])
+		s.feed(r)
+		i += size
+	}
+	return len(p), nil
+}
+
+// feed 以 ECMA-48 狀態機處理一個字元，規則與 StripANSI 相同。
+func (s *Screen) feed(r rune) {
+	switch {
+	case r == runeESC:
+		if s.state == stateOSC {
+			s.dispatchOSC()
+		}
+		s.state = stateEscape
+		s.inter = s.inter[:0]
+		return
+	case r == runeCAN || r == runeSUB:
+		s.state = stateGround
+		return
+	case r >= 0x80 && r <= 0x9f:
+		if s.state == stateOSC && r == runeST {
+			s.dispatchOSC()
+		}
+		s.enter(c1State(r))
+		return
+	}
+
+	switch s.state {
+	case stateGround:
+		switch {
+		case r < 0x20:
+			s.execute(r)
+		case r == runeDEL:
+		default:
+			s.print(r)
+		}
+
+	case stateEscape, stateEscInter:
+		switch {
+		case r < 0x20:
+			s.execute(r)
+		case r <= 0x2f:
+			s.inter = append(s.inter, byte(r))
+			s.state = stateEscInter
+		case s.state == stateEscape && r == '[':
+			s.enter(stateCSI)
+		case s.state == stateEscape && r == ']':
+			s.enter(stateOSC)
+		case s.state == stateEscape && (r == 'P' || r == 'X' || r == '^' || r == '_'):
+			s.enter(stateString)
+		case r < runeDEL:
+			s.state = stateGround
+			s.escDispatch(r)
+		case r == runeDEL:
+		default:
+			s.state = stateGround
+			s.feed(r)
+		}
+
+	case stateCSI:
+		switch {
+		case r < 0x20:
+			s.execute(r)
+		case r >= '<' && r <= '?' && len(s.params) == 0 && s.private == 0:
+			s.private = byte(r)
+		case r <= 0x2f:
+			s.inter = append(s.inter, byte(r))
+		case r <= 0x3f:
+			s.params = append(s.params, byte(r))
+		case r < runeDEL:
+			s.state = stateGround
+			s.csiDispatch(r)
+		case r == runeDEL:
+		default:
+			s.state = stateGround
+			s.feed(r)
+		}
+
+	case stateOSC:
+		if r == runeBEL {
+			s.dispatchOSC()
+			s.state = stateGround
+		} else if len(s.osc) < maxOSCLength {
+			s.osc = utf8.AppendRune(s.osc, r)
+		}
+
+	case stateString:
+		if r == runeBEL {
+			s.state = stateGround
+		}
+	}
+}
+
+// enter 進入新的序列狀態並清除收集中的參數。
+func (s *Screen) enter(state ansiState) {
+	s.state = state
+	s.private = 0
+	s.params = s.params[:0]
+	s.inter = s.inter[:0]
+	s.osc = s.osc[:0]
+}
+
+// execute 處理 C0 控制字元。
+func (s *Screen) execute(r rune) {
+	switch r {
+	case '\b':
+		if s.col > 0 {
+			s.col--
+		}
+		s.wrapPending = false
+	case '\t':
+		s.col = min((s.col/tabWidth+1)*tabWidth, s.width-1)
+		s.wrapPending = false
+	case '\n', '\v', '\f':
+		s.lineFeed()
+		if s.newlineMode {
+			s.col = 0
+		}
+	case '\r':
+		s.col = 0
+		s.wrapPending = false
+	}
+}
+
+// print 在游標位置寫入可見字元。
+func (s *Screen) print(r rune) {
+	w := runewidth.RuneWidth(r)
+	if w == 0 {
+		return // 組合字元與零寬字元不佔儲存格
+	}
+	if s.wrapPending && s.autowrap {
+		s.col = 0
+		s.lineFeed()
+	}
+	s.wrapPending = false
+	if w == 2 && s.col == s.width-1 {
+		if !s.autowrap {
+			return
+		}
+		s.cells[s.row][s.col] = BlankCell
+		s.col = 0
+		s.lineFeed()
+	}
+
+	line := s.cells[s.row]
+	line[s.col] = Cell{Rune: r, Attr: s.attr}
+	if w == 2 && s.col+1 < s.width {
+		line[s.col+1] = Cell{Rune: 0, Attr: s.attr}
+	}
+	s.col += w
+	if s.col >= s.width {
+		s.col = s.width - 1
+		s.wrapPending = s.autowrap
+	}
+}
+
+// lineFeed 將游標下移一列，到達捲動區域底部時捲動。
+func (s *Screen) lineFeed() {
+	s.wrapPending = false
+	if s.row == s.bottom {
+		s.scrollUp(1)
+	} else if s.row < s.height-1 {
+		s.row++
+	}
+}
+
+// reverseIndex 將游標上移一列，到達捲動區域頂端時向下捲動。
+func (s *Screen) reverseIndex() {
+	s.wrapPending = false
+	if s.row == s.top {
+		s.scrollDown(1)
+	} else if s.row > 0 {
+		s.row--
+	}
+}
+
+// scrollUp 將捲動區域內容上移 n 列，底部補空白列。
+func (s *Screen) scrollUp(n int) {
+	s.deleteLinesAt(s.top, n)
+}
+
+// scrollDown 將捲動區域內容下移 n 列，頂端補空白列。
+func (s *Screen) scrollDown(n int) {
+	s.insertLinesAt(s.top, n)
+}
+
+// insertLinesAt 在捲動區域的第 at 列插入 n 列空白列。
+func (s *Screen) insertLinesAt(at, n int) {
+	n = min(n, s.bottom-at+1)
+	for i := s.bottom; i >= at+n; i-- {
+		s.cells[i] = s.cells[i-n]
+	}
+	for i := at; i < at+n; i++ {
+		s.cells[i] = newRow(s.width)
+	}
+}
+
+// deleteLinesAt 刪除捲動區域中自第 at 列起的 n 列，底部補空白列。
+func (s *Screen) deleteLinesAt(at, n int) {
+	n = min(n, s.bottom-at+1)
+	for i := at; i+n <= s.bottom; i++ {
+		s.cells[i] = s.cells[i+n]
+	}
+	for i := s.bottom - n + 1; i <= s.bottom; i++ {
+		s.cells[i] = newRow(s.width)
+	}
+}
+
+// escDispatch 處理兩位元組的 ESC 序列。含中間位元組者（例如字集切換 ESC ( B）一律忽略。
+func (s *Screen) escDispatch(final rune) {
+	if len(s.inter) > 0 {
+		return
+	}
+	switch final {
+	case '7':
+		s.saveCursor()
+	case '8':
+		s.restoreCursor()
+	case 'D':
+		s.lineFeed()
+	case 'E':
+		s.col = 0
+		s.lineFeed()
+	case 'M':
+		s.reverseIndex()
+	case 'c':
+		s.reset()
+	}
+}
+
+// csiParams 將 CSI 參數拆成數字，子參數（以 : 分隔）保留在同一組中。
+func (s *Screen) csiParams() [][]int {
+	if len(s.params) == 0 {
+		return nil
+	}
+	var out [][]int
+	for _, group := range strings.Split(string(s.params), ";") {
+		var sub []int
+		for _, p := range strings.Split(group, ":") {
+			n, _ := strconv.Atoi(p)
+			sub = append(sub, n)
+		}
+		out = append(out, sub)
+	}
+	return out
+}
+
+// param 回傳第 i 個參數，缺少或為 0 時回傳 def。
+func param(params [][]int, i, def int) int {
+	if i >= len(params) || params[i][0] == 0 {
+		return def
+	}
+	return params[i][0]
+}
+
+// csiDispatch 處理 CSI 序列。
+func (s *Screen) csiDispatch(final rune) {
+	params := s.csiParams()
+	if len(s.inter) > 0 {
+		return
+	}
+	if s.private != 0 {
+		if s.private == '?' && (final == 'h' || final == 'l') {
+			for _, p := range params {
+				s.setPrivateMode(p[0], final == 'h')
+			}
+		}
+		return
+	}
+
+	n := param(params, 0, 1)
+	switch final {
+	case 'A':
+		s.moveTo(s.row-n, s.col)
+	case 'B', 'e':
+		s.moveTo(s.row+n, s.col)
+	case 'C', 'a':
+		s.moveTo(s.row, s.col+n)
+	case 'D':
+		s.moveTo(s.row, s.col-n)
+	case 'E':
+		s.moveTo(s.row+n, 0)
+	case 'F':
+		s.moveTo(s.row-n, 0)
+	case 'G', '`':
+		s.moveTo(s.row, n-1)
+	case 'd':
+		s.moveTo(n-1, s.col)
+	case 'H', 'f':
+		s.moveTo(param(params, 0, 1)-1, param(params, 1, 1)-1)
+	case 'J':
+		s.eraseDisplay(param(params, 0, 0))
+	case 'K':
+		s.eraseLine(param(params, 0, 0))
+	case 'L':
+		if s.row >= s.top && s.row <= s.bottom {
+			s.insertLinesAt(s.row, n)
+			s.col = 0
+		}
+	case 'M':
+		if s.row >= s.top && s.row <= s.bottom {
+			s.deleteLinesAt(s.row, n)
+			s.col = 0
+		}
+	case '@':
+		s.insertChars(n)
+	case 'P':
+		s.deleteChars(n)
+	case 'X':
+		s.eraseCells(s.row, s.col, min(s.col+n, s.width))
+	case 'S':
+		s.scrollUp(n)
+	case 'T':
+		s.scrollDown(n)
+	case 'r':
+		top, bottom := param(params, 0, 1)-1, param(params, 1, s.height)-1
+		if top < bottom && bottom < s.height {
+			s.top, s.bottom = top, bottom
+			s.moveTo(0, 0)
+		}
+	case 's':
+		s.saveCursor()
+	case 'u':
+		s.restoreCursor()
+	case 'm':
+		s.sgr(params)
+	}
+	s.wrapPending = false
+}
+
+// moveTo 將游標移到指定位置並限制在畫面內。
+func (s *Screen) moveTo(row, col int) {
+	s.row = min(max(row, 0), s.height-1)
+	s.col = min(max(col, 0), s.width-1)
+	s.wrapPending = false
+}
+
+// eraseCells 清除第 row 列 [from, to) 的儲存格。
+func (s *Screen) eraseCells(row, from, to int) {
+	for i := from; i < to; i++ {
+		s.cells[row][i] = BlankCell
+	}
+}
+
+// eraseDisplay 處理 ED：0 清除游標之後、1 清除游標之前、2／3 清除全部。
+func (s *Screen) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		s.eraseCells(s.row, s.col, s.width)
+		for r := s.row + 1; r < s.height; r++ {
+			s.cells[r] = newRow(s.width)
+		}
+	case 1:
+		for r := 0; r < s.row; r++ {
+			s.cells[r] = newRow(s.width)
+		}
+		s.eraseCells(s.row, 0, s.col+1)
+	case 2, 3:
+		s.cells = newGrid(s.width, s.height)
+	}
+}
+
+// eraseLine 處理 EL：0 清除游標之後、1 清除游標之前、2 清除整列。
+func (s *Screen) eraseLine(mode int) {
+	switch mode {
+	case 0:
+		s.eraseCells(s.row, s.col, s.width)
+	case 1:
+		s.eraseCells(s.row, 0, s.col+1)
+	case 2:
+		s.eraseCells(s.row, 0, s.width)
+	}
+}
+
+// insertChars 在游標處插入 n 個空白，右側內容右移。
+func (s *Screen) insertChars(n int) {
+	line := s.cells[s.row]
+	n = min(n, s.width-s.col)
+	copy(line[s.col+n:], line[s.col:s.width-n])
+	s.eraseCells(s.row, s.col, s.col+n)
+}
+
+// deleteChars 刪除游標處的 n 個字元，右側內容左移。
+func (s *Screen) deleteChars(n int) {
+	line := s.cells[s.row]
+	n = min(n, s.width-s.col)
+	copy(line[s.col:], line[s.col+n:])
+	s.eraseCells(s.row, s.width-n, s.width)
+}
+
+// saveCursor 保存游標位置與屬性。
+func (s *Screen) saveCursor() {
+	s.saved = savedCursor{row: s.row, col: s.col, attr: s.attr}
+}
+
+// restoreCursor 還原保存的游標位置與屬性。
+func (s *Screen) restoreCursor() {
+	s.moveTo(s.saved.row, s.saved.col)
+	s.attr = s.saved.attr
+}
+
+// setPrivateMode 處理 DECSET／DECRST。
+func (s *Screen) setPrivateMode(mode int, on bool) {
+	switch mode {
+	case 7:
+		s.autowrap = on
+	case 25:
+		s.cursorVisible = on
+	case 47, 1047, 1049:
+		if on == s.altActive {
+			return
+		}
+		if on {
+			if mode == 1049 {
+				s.saveCursor()
+			}
+			s.main = s.cells
+			s.cells = newGrid(s.width, s.height)
+		} else {
+			s.cells = s.main
+			s.main = nil
+			if mode == 1049 {
+				s.restoreCursor()
+			}
+		}
+		s.altActive = on
+	}
+}
+
+// sgr 處理 SGR（Select Graphic Rendition）。
+func (s *Screen) sgr(params [][]int) {
+	if len(params) == 0 {
+		s.attr = defaultAttr
+		return
+	}
+	for i := 0; i < len(params); i++ {
+		p := params[i]
+		switch code := p[0]; {
+		case code == 0:
+			s.attr = defaultAttr
+		case code == 1:
+			s.attr.Bold = true
+		case code == 2:
+			s.attr.Dim = true
+		case code == 3:
+			s.attr.Italic = true
+		case code == 4:
+			s.attr.Underline = len(p) < 2 || p[1] != 0
+		case code == 5 || code == 6:
+			s.attr.Blink = true
+		case code == 7:
+			s.attr.Reverse = true
+		case code == 8:
+			s.attr.Hidden = true
+		case code == 9:
+			s.attr.Strike = true
+		case code == 21:
+			s.attr.Underline = true
+		case code == 22:
+			s.attr.Bold, s.attr.Dim = false, false
+		case code == 23:
+			s.attr.Italic = false
+		case code == 24:
+			s.attr.Underline = false
+		case code == 25:
+			s.attr.Blink = false
+		case code == 27:
+			s.attr.Reverse = false
+		case code == 28:
+			s.attr.Hidden = false
+		case code == 29:
+			s.attr.Strike = false
+		case code >= 30 && code <= 37:
+			s.attr.Fg = Color(code - 30)
+		case code == 38:
+			var c Color
+			c, i = extendedColor(params, i)
+			s.attr.Fg = c
+		case code == 39:
+			s.attr.Fg = ColorDefault
+		case code >= 40 && code <= 47:
+			s.attr.Bg = Color(code - 40)
+		case code == 48:
+			var c Color
+			c, i = extendedColor(params, i)
+			s.attr.Bg = c
+		case code == 49:
+			s.attr.Bg = ColorDefault
+		case code >= 90 && code <= 97:
+			s.attr.Fg = Color(code - 90 + 8)
+		case code >= 100 && code <= 107:
+			s.attr.Bg = Color(code - 100 + 8)
+		}
+	}
+}
+
+// extendedColor 解析 38／48 的延伸色彩，支援分號（38;5;n）與冒號（38:2::r:g:b）兩種寫法，
+// 回傳顏色與最後使用的參數索引。
+func extendedColor(params [][]int, i int) (Color, int) {
+	if sub := params[i]; len(sub) > 1 {
+		switch {
+		case sub[1] == 5 && len(sub) >= 3:
+			return Color(sub[2] & 0xff), i
+		case sub[1] == 2 && len(sub) >= 6:
+			return RGBColor(uint8(sub[3]), uint8(sub[4]), uint8(sub[5])), i
+		case sub[1] == 2 && len(sub) == 5:
+			return RGBColor(uint8(sub[2]), uint8(sub[3]), uint8(sub[4])), i
+		}
+		return ColorDefault, i
+	}
+	if i+1 >= len(params) {
+		return ColorDefault, i
+	}
+	switch params[i+1][0] {
+	case 5:
+		if i+2 < len(params) {
+			return Color(params[i+2][0] & 0xff), i + 2
+		}
+	case 2:
+		if i+4 < len(params) {
+			return RGBColor(uint8(params[i+2][0]), uint8(params[i+3][0]), uint8(params[i+4][0])), i + 4
+		}
+	}
+	return ColorDefault, len(params) - 1
+}
+
+// dispatchOSC 處理 OSC 字串，目前只記錄視窗標題（OSC 0／2）。
+func (s *Screen) dispatchOSC() {
+	code, text, ok := strings.Cut(string(s.osc), ";")
+	if ok && (code == "0" || code == "2") {
+		s.title = text
+	}
+	s.osc = s.osc[:0]
+}
+
+// Size 回傳畫面的寬與高。
+func (s *Screen) Size() (width, height int) { return s.width, s.height }
+
+// Cursor 回傳游標所在的列與欄（從 0 開始）。
+func (s *Screen) Cursor() (row, col int) { return s.row, s.col }
+
+// SetCursor 設定游標位置，例如 capture-pane 之後以 #{cursor_y}／#{cursor_x} 校正。
+func (s *Screen) SetCursor(row, col int) { s.moveTo(row, col) }
+
+// CursorVisible 回傳游標是否顯示（DECTCEM）。
+func (s *Screen) CursorVisible() bool { return s.cursorVisible }
+
+// AltScreen 回傳是否正在使用替代畫面。
+func (s *Screen) AltScreen() bool { return s.altActive }
+
+// Title 回傳最後一次以 OSC 0／2 設定的標題。
+func (s *Screen) Title() string { return s.title }
+
+// Cell 回傳指定位置的儲存格，超出範圍時回傳空白。
+func (s *Screen) Cell(row, col int) Cell {
+	if row < 0 || row >= s.height || col < 0 || col >= s.width {
+		return BlankCell
+	}
+	return s.cells[row][col]
+}
+
+// Line 回傳第 row 列的文字（去除結尾空白）。
+func (s *Screen) Line(row int) string {
+	if row < 0 || row >= s.height {
+		return ""
+	}
+	var b strings.Builder
+	for _, c := range s.cells[row] {
+		if c.Rune != 0 {
+			b.WriteRune(c.Rune)
+		}
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// Lines 回傳所有列的文字。
+func (s *Screen) Lines() []string {
+	lines := make([]string, s.height)
+	for i := range lines {
+		lines[i] = s.Line(i)
+	}
+	return lines
+}
+
+// String 回傳畫面文字，去除結尾的空白列。
+func (s *Screen) String() string {
+	return joinTrimmed(s.Lines())
+}
+
+// NearCursor 回傳游標上方 above 列起到畫面最後一個非空白列的文字，
+// 用來判斷「目前畫面上、游標附近」的狀態，而非整段歷史紀錄。
+func (s *Screen) NearCursor(above int) string {
+	return joinTrimmed(s.Lines()[max(s.row-above, 0):])
+}
+
+// joinTrimmed 以換行連接各列並去除結尾的空白列。
+func joinTrimmed(lines []string) string {
+	end := len(lines)
+	for end > 0 && lines[end-1] == "" {
+		end--
+	}
+	return strings.Join(lines[:end], "\n")
+}
@@ -0,0 +1,243 @@
+package tmux_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+)
+
+func writeScreen(s *tmux.Screen, data string) {
+	_, _ = s.Write([]byte(data))
+}
+
+func TestScreen_PrintAndWrap(t *testing.T) {
+	s := tmux.NewScreen(5, 3)
+	writeScreen(s, "hello world")
+
+	assert.Equal(t, []string{"hello", " worl", "d"}, s.Lines())
+	row, col := s.Cursor()
+	assert.Equal(t, 2, row)
+	assert.Equal(t, 1, col)
+}
+
+func TestScreen_ScrollAtBottom(t *testing.T) {
+	s := tmux.NewScreen(10, 2)
+	writeScreen(s, "one\r\ntwo\r\nthree")
+
+	assert.Equal(t, "two\nthree", s.String())
+}
+
+func TestScreen_CursorMovementAndErase(t *testing.T) {
+	s := tmux.NewScreen(10, 4)
+	writeScreen(s, "aaaaaaaaaa\r\nbbbbbbbbbb\r\ncccccccccc")
+	writeScreen(s, "\033[2;3H")  // 第 2 列第 3 欄
+	writeScreen(s, "\033[K")     // 清除到行尾
+	writeScreen(s, "\033[1;1HX") // 回到左上角
+	writeScreen(s, "\033[3;5H\033[1K")
+
+	assert.Equal(t, []string{"Xaaaaaaaaa", "bb", "     ccccc", ""}, s.Lines())
+
+	writeScreen(s, "\033[2J")
+	assert.Equal(t, "", s.String())
+}
+
+func TestScreen_InsertDeleteLines(t *testing.T) {
+	s := tmux.NewScreen(5, 4)
+	writeScreen(s, "1\r\n2\r\n3\r\n4")
+	writeScreen(s, "\033[2;1H\033[L")
+	assert.Equal(t, []string{"1", "", "2", "3"}, s.Lines())
+
+	writeScreen(s, "\033[M\033[M")
+	assert.Equal(t, []string{"1", "3", "", ""}, s.Lines())
+}
+
+func TestScreen_ScrollRegion(t *testing.T) {
+	s := tmux.NewScreen(10, 4)
+	writeScreen(s, "header\r\n")
+	writeScreen(s, "\033[2;3r") // 捲動區域：第 2–3 列
+	writeScreen(s, "\033[4;1Hfooter")
+	writeScreen(s, "\033[2;1Ha\r\nb\r\nc")
+
+	assert.Equal(t, []string{"header", "b", "c", "footer"}, s.Lines())
+}
+
+func TestScreen_SGR(t *testing.T) {
+	s := tmux.NewScreen(20, 1)
+	writeScreen(s, "\033[1;31mA\033[0mB\033[38;5;208mC\033[48;2;10;20;30mD\033[38:2::1:2:3;7mE\033[39;49;27;22mF\033[94mG")
+
+	a := s.Cell(0, 0).Attr
+	assert.True(t, a.Bold)
+	assert.Equal(t, tmux.Color(1), a.Fg)
+
+	assert.Equal(t, tmux.ColorDefault, s.Cell(0, 1).Attr.Fg)
+	assert.Equal(t, tmux.Color(208), s.Cell(0, 2).Attr.Fg)
+
+	r, g, b := s.Cell(0, 3).Attr.Bg.RGB()
+	assert.Equal(t, []uint8{10, 20, 30}, []uint8{r, g, b})
+
+	e := s.Cell(0, 4).Attr
+	assert.True(t, e.Fg.IsRGB())
+	assert.True(t, e.Reverse)
+
+	f := s.Cell(0, 5).Attr
+	assert.Equal(t, tmux.ColorDefault, f.Fg)
+	assert.Equal(t, tmux.ColorDefault, f.Bg)
+	assert.False(t, f.Reverse)
+	assert.Equal(t, tmux.Color(12), s.Cell(0, 6).Attr.Fg)
+}
+
+func TestScreen_AltScreen(t *testing.T) {
+	s := tmux.NewScreen(10, 3)
+	writeScreen(s, "$ vim")
+	writeScreen(s, "\033[?1049h\033[Hediting")
+	assert.True(t, s.AltScreen())
+	assert.Equal(t, "editing", s.String())
+
+	writeScreen(s, "\033[?1049l")
+	assert.False(t, s.AltScreen())
+	assert.Equal(t, "$ vim", s.String())
+	_, col := s.Cursor()
+	assert.Equal(t, 5, col)
+}
+
+func TestScreen_OSCAndCharset(t *testing.T) {
+	s := tmux.NewScreen(20, 2)
+	writeScreen(s, "\033]0;✳ Claude Code\007\033(B\033]8;;https://x\033\\link\033]8;;\033\\ \033[?25l")
+
+	assert.Equal(t, "✳ Claude Code", s.Title())
+	assert.Equal(t, "link", s.String())
+	assert.False(t, s.CursorVisible())
+}
+
+func TestScreen_WideCharsAndSplitUTF8(t *testing.T) {
+	s := tmux.NewScreen(6, 2)
+	data := []byte("你好❯ ok")
+	// 逐位元組寫入，確認跨 Write 的 UTF-8 會被正確組合
+	for _, b := range data {
+		_, _ = s.Write([]byte{b})
+	}
+
+	assert.Equal(t, []string{"你好❯", "ok"}, s.Lines())
+	assert.Equal(t, '你', s.Cell(0, 0).Rune)
+	assert.Equal(t, rune(0), s.Cell(0, 1).Rune)
+}
+
+func TestScreen_LoadCapture(t *testing.T) {
+	// capture-pane 的每一行以 \n 分隔，超過畫面高度的歷史紀錄會捲出
+	captured := "old 1\nold 2\n\033[32mvisible 1\nvisible 2\n❯ \n"
+	s := tmux.NewScreen(20, 3)
+	s.LoadCapture(captured)
+	s.SetCursor(2, 2)
+
+	assert.Equal(t, []string{"visible 1", "visible 2", "❯"}, s.Lines())
+	assert.Equal(t, tmux.Color(2), s.Cell(0, 0).Attr.Fg)
+	row, col := s.Cursor()
+	assert.Equal(t, 2, row)
+	assert.Equal(t, 2, col)
+}
+
+func TestScreen_NearCursor(t *testing.T) {
+	s := tmux.NewScreen(20, 10)
+	s.LoadCapture("a\nb\nc\nd\ne\nf\n\n")
+	s.SetCursor(4, 0)
+
+	assert.Equal(t, "c\nd\ne\nf", s.NearCursor(2))
+	assert.Equal(t, "a\nb\nc\nd\ne\nf", s.NearCursor(20))
+}
+
+func TestDetectScreenStatus_IgnoresStaleOutput(t *testing.T) {
+	// 舊的忙碌指標仍在畫面上方，但游標附近已是新的提示字元
+	lines := []string{"✻ Thinking… (esc to interrupt)", "Done."}
+	for i := 0; i < 20; i++ {
+		lines = append(lines, "output line")
+	}
+	lines = append(lines, "╭────────╮", "│ ❯      │", "╰────────╯", "  ? for shortcuts")
+	content := strings.Join(lines, "\n")
+
+	s := tmux.NewScreen(40, len(lines))
+	s.LoadCapture(content)
+	s.SetCursor(len(lines)-3, 4)
+
+	assert.Equal(t, tmux.StatusRunning, tmux.DetectStatus(content), "substring search is fooled by stale output")
+	in := tmux.AgentInput{Command: "claude"}
+	assert.Equal(t, tmux.StatusIdle, tmux.DetectScreenStatus(in, s))
+
+	writeScreen(s, "\033[22;1H\033[K· Pondering… (esc to interrupt)")
+	assert.Equal(t, tmux.StatusRunning, tmux.DetectScreenStatus(in, s))
+}
+
+func TestManager_CaptureScreen(t *testing.T) {
+	exec := &mockExecutor{outputs: map[string]string{
+		"capture-pane -t %1 -p -e -S -10": "\033[2mhistory\033[0m\nline 1\n\033[1mline 2",
+	}}
+	mgr := tmux.NewManager(exec)
+
+	// 畫面只有兩列，歷史紀錄只出現在純文字內容中
+	scr, content, err := mgr.CaptureScreen(tmux.Pane{ID: "%1", Width: 20, Height: 2, CursorX: 3, CursorY: 1}, 10)
+	require.NoError(t, err)
+	require.NotNil(t, scr)
+	assert.Equal(t, "line 1\nline 2", scr.String())
+	assert.Equal(t, "history\nline 1\nline 2", content)
+	assert.True(t, scr.Cell(1, 0).Attr.Bold)
+	row, col := scr.Cursor()
+	assert.Equal(t, 1, row)
+	assert.Equal(t, 3, col)
+
+	scr, content, err = mgr.CaptureScreen(tmux.Pane{ID: "%1"}, 10)
+	assert.NoError(t, err)
+	assert.Nil(t, scr)
+	assert.Equal(t, "history\nline 1\nline 2", content)
+}
+
+// rawExecutor 以 mockExecutor 的輸出作為未修剪的輸出，並記錄 Execute 是否被呼叫。
+type rawExecutor struct {
+	mockExecutor
+	trimmed bool
+}
+
+func (r *rawExecutor) Execute(args ...string) (string, error) {
+	r.trimmed = true
+	return r.mockExecutor.Execute(args...)
+}
+
+func (r *rawExecutor) ExecuteRaw(args ...string) (string, error) {
+	return r.mockExecutor.Execute(args...)
+}
+
+func TestManager_CaptureScreen_LeadingBlankLines(t *testing.T) {
+	// 畫面上方三列空白，提示字元在第 4 列，最後一列空白
+	exec := &rawExecutor{mockExecutor: mockExecutor{outputs: map[string]string{
+		"capture-pane -t %1 -p -e": "\n\n\n\033[1m❯\033[0m ready\n\n",
+	}}}
+	mgr := tmux.NewManager(exec)
+
+	scr, _, err := mgr.CaptureScreen(tmux.Pane{ID: "%1", Width: 20, Height: 5, CursorX: 2, CursorY: 3}, 0)
+	require.NoError(t, err)
+	assert.False(t, exec.trimmed)
+	assert.Equal(t, "❯ ready", scr.Line(3))
+	assert.Equal(t, "", scr.Line(0))
+	assert.Equal(t, "❯ ready", scr.NearCursor(0))
+}
+
+func FuzzScreenWrite(f *testing.F) {
+	f.Add("hello\r\nworld", 10, 3)
+	f.Add("\033[2;3r\033[5L\033[99M\033[?1049h\033[200@x\033[300P", 4, 2)
+	f.Add("你\033[1;999H好\033[38:2::1:2:3m\033[48;5m", 1, 1)
+	f.Fuzz(func(t *testing.T, data string, width, height int) {
+		width, height = width%200, height%100
+		s := tmux.NewScreen(width, height)
+		writeScreen(s, data)
+
+		w, h := s.Size()
+		row, col := s.Cursor()
+		if row < 0 || row >= h || col < 0 || col >= w {
+			t.Fatalf("cursor out of bounds: %d,%d in %dx%d", row, col, w, h)
+		}
+		if strings.ContainsRune(s.String(), '\033') {
+			t.Fatalf("escape byte on screen for %q", data)
+		}
+	})
+}
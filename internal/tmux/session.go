@@ -1,9 +1,13 @@
 package tmux
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"os/exec"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 )
 
@@ -48,6 +52,79 @@ func ParseListSessions(output string) ([]Session, error) {
 	return sessions, nil
 }
 
+// ListPanesFormat 是傳給 tmux list-panes -a -F 的格式字串，以 tab 分隔；
+// pane title 可能含任意字元，因此放在最後。
+const ListPanesFormat = "#{session_name}\t#{window_index}\t#{window_name}\t#{pane_index}\t#{pane_id}\t#{pane_pid}\t#{pane_current_command}\t#{pane_active}\t#{pane_width}\t#{pane_height}\t#{cursor_x}\t#{cursor_y}\t#{pane_title}"
+
+// ParseListPanes 解析 tmux list-panes -a 的輸出，回傳 Pane 切片。
+func ParseListPanes(output string) ([]Pane, error) {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return nil, nil
+	}
+
+	lines := strings.Split(output, "\n")
+	panes := make([]Pane, 0, len(lines))
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "\t", 13)
+		if len(parts) < 13 {
+			return nil, fmt.Errorf("unexpected format: %q", line)
+		}
+		windowIndex, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid window index %q: %w", parts[1], err)
+		}
+		paneIndex, err := strconv.Atoi(parts[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pane index %q: %w", parts[3], err)
+		}
+		pid, err := strconv.Atoi(parts[5])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pane pid %q: %w", parts[5], err)
+		}
+		var geometry [4]int // 寬、高、游標欄、游標列
+		for k := range geometry {
+			if geometry[k], err = strconv.Atoi(parts[8+k]); err != nil {
+				return nil, fmt.Errorf("invalid pane geometry %q: %w", parts[8+k], err)
+			}
+		}
+		panes = append(panes, Pane{
+			SessionName: parts[0],
+			WindowIndex: windowIndex,
+			WindowName:  parts[2],
+			Index:       paneIndex,
+			ID:          parts[4],
+			PID:         pid,
+			Command:     parts[6],
+			Active:      parts[7] == "1",
+			Width:       geometry[0],
+			Height:      geometry[1],
+			CursorX:     geometry[2],
+			CursorY:     geometry[3],
+			Title:       parts[12],
+		})
+	}
+	return panes, nil
+}
+
+// GroupWindows 依 window index 將 pane 分組，並彙整每個 window 的狀態。
+// pane 的順序需與 list-panes 輸出相同（依 window、pane index 排列）。
+func GroupWindows(panes []Pane) []Window {
+	var windows []Window
+	for _, p := range panes {
+		if n := len(windows); n == 0 || windows[n-1].Index != p.WindowIndex {
+			windows = append(windows, Window{Index: p.WindowIndex, Name: p.WindowName})
+		}
+		w := &windows[len(windows)-1]
+		w.Panes = append(w.Panes, p)
+		w.Status = RollupStatus(w.Status, p.Status)
+	}
+	return windows
+}
+
 // Manager 封裝 tmux 操作，透過 Executor 介面執行指令。
 type Manager struct {
 	exec Executor
@@ -58,6 +135,14 @@ func NewManager(exec Executor) *Manager {
 	return &Manager{exec: exec}
 }
 
+// executeRaw 以 RawExecutor 執行指令取得未修剪的輸出，executor 不支援時退回 Execute。
+func (m *Manager) executeRaw(args ...string) (string, error) {
+	if r, ok := m.exec.(RawExecutor); ok {
+		return r.ExecuteRaw(args...)
+	}
+	return m.exec.Execute(args...)
+}
+
 // ListSessions 列出所有 tmux session。
 func (m *Manager) ListSessions() ([]Session, error) {
 	output, err := m.exec.Execute("list-sessions", "-F", ListSessionsFormat)
@@ -67,25 +152,186 @@ func (m *Manager) ListSessions() ([]Session, error) {
 	return ParseListSessions(output)
 }
 
+// exactSession 回傳只比對完整名稱的 session 目標；tmux 預設會以前綴比對名稱，
+// 不存在的名稱可能誤中另一個 session（例如 "api" 對應到 "api-v2"）。
+func exactSession(name string) string {
+	return "=" + name
+}
+
+// SessionPane 回傳 session 目前 window 的 active pane 的目標，以完整名稱比對 session，
+// 可用於 send-keys 等需要 pane 目標的指令。
+func SessionPane(name string) string {
+	return exactSession(name) + ":"
+}
+
 // KillSession 刪除指定的 session。
 func (m *Manager) KillSession(name string) error {
-	_, err := m.exec.Execute("kill-session", "-t", name)
+	_, err := m.exec.Execute("kill-session", "-t", exactSession(name))
 	return err
 }
 
 // RenameSession 重新命名 session。
 func (m *Manager) RenameSession(oldName, newName string) error {
-	_, err := m.exec.Execute("rename-session", "-t", oldName, newName)
+	_, err := m.exec.Execute("rename-session", "-t", exactSession(oldName), newName)
 	return err
 }
 
+// ValidateSessionName 檢查 session 名稱是否可用：不可為空，也不可包含 tmux 用於
+// 分隔目標的 "." 與 ":"（tmux 會自動將它們改為 "_"，造成名稱與預期不符）。
+func ValidateSessionName(name string) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return errors.New("session name must not be empty")
+	case strings.ContainsAny(name, ".:"):
+		return fmt.Errorf("session name %q must not contain '.' or ':'", name)
+	}
+	return nil
+}
+
 // NewSession 建立新的 detached session。
 func (m *Manager) NewSession(name, path string) error {
 	_, err := m.exec.Execute("new-session", "-d", "-s", name, "-c", path)
 	return err
 }
 
-// CapturePane 擷取指定 session 的 pane 內容。
+// ServerID 回傳 tmux server 的識別（pid 與啟動時間）。server 重新啟動後 session id 會重新編號，
+// 可用此值判斷先前記錄的 id 是否仍然有效。
+func (m *Manager) ServerID() (string, error) {
+	return m.exec.Execute("display-message", "-p", "#{pid}:#{start_time}")
+}
+
+// PaneSession 回傳指定 pane（例如 $TMUX_PANE 的 "%3"）所屬的 session 名稱。
+func (m *Manager) PaneSession(paneID string) (string, error) {
+	return m.exec.Execute("display-message", "-p", "-t", paneID, "#{session_name}")
+}
+
+// PaneSessionID 回傳指定 pane 所屬 session 的 id（例如 "$3"）與名稱。
+func (m *Manager) PaneSessionID(paneID string) (id, name string, err error) {
+	out, err := m.exec.Execute("display-message", "-p", "-t", paneID, "#{session_id}:#{session_name}")
+	if err != nil {
+		return "", "", err
+	}
+	id, name, ok := strings.Cut(out, ":")
+	if !ok {
+		return "", "", fmt.Errorf("unexpected session format: %q", out)
+	}
+	return id, name, nil
+}
+
+// InsideTmux 回傳目前程序是否在 tmux 內執行（依 $TMUX 判斷）。
+func InsideTmux() bool {
+	return os.Getenv("TMUX") != ""
+}
+
+// InsidePopup 回傳目前程序是否在 tmux popup 中執行。
+// tmux 只為 pane 設定 $TMUX_PANE，popup 中的程序只有 $TMUX。
+func InsidePopup() bool {
+	return InsideTmux() && os.Getenv("TMUX_PANE") == ""
+}
+
+// SwitchClient 將目前的 tmux client 切換到指定 session。
+func (m *Manager) SwitchClient(target string) error {
+	_, err := m.exec.Execute("switch-client", "-t", exactSession(target))
+	return err
+}
+
+// Attach 連線到指定 session。在 tmux 內使用 switch-client；
+// 在 tmux 外以 attach-session 取代目前程序，呼叫前必須先還原終端（離開 alt screen）。
+func (m *Manager) Attach(target string) error {
+	if InsideTmux() {
+		return m.SwitchClient(target)
+	}
+	path, err := exec.LookPath("tmux")
+	if err != nil {
+		return err
+	}
+	return syscall.Exec(path, []string{"tmux", "attach-session", "-t", exactSession(target)}, os.Environ())
+}
+
+// CapturePane 擷取指定目標（session 名稱或 pane id）的 pane 內容。
 func (m *Manager) CapturePane(name string, lines int) (string, error) {
 	return m.exec.Execute("capture-pane", "-t", name, "-p", "-S", fmt.Sprintf("-%d", lines))
 }
+
+// CapturePaneANSI 與 CapturePane 相同，但以 -e 保留顏色等屬性的跳脫序列。
+func (m *Manager) CapturePaneANSI(name string, lines int) (string, error) {
+	return m.exec.Execute("capture-pane", "-t", name, "-p", "-e", "-S", fmt.Sprintf("-%d", lines))
+}
+
+// CaptureScreen 以一次 capture-pane（含 -e 的屬性序列）擷取 pane 的可見畫面與最近 history 列
+// 歷史紀錄。可見畫面建立為畫面模型並以 list-panes 取得的游標位置校正，pane 沒有大小資訊時
+// scr 為 nil；content 是歷史紀錄加上可見畫面的純文字，供判斷 agent 與模型。
+// 擷取結果以未修剪的輸出對齊 pane 高度，畫面上方的空白列不會讓內容錯位。
+func (m *Manager) CaptureScreen(p Pane, history int) (scr *Screen, content string, err error) {
+	args := []string{"capture-pane", "-t", p.ID, "-p", "-e"}
+	if history > 0 {
+		args = append(args, "-S", fmt.Sprintf("-%d", history))
+	}
+	output, err := m.executeRaw(args...)
+	if err != nil {
+		return nil, "", err
+	}
+	output = strings.TrimSuffix(output, "\n")
+	content = strings.TrimSpace(StripANSI(output))
+	if p.Width <= 0 || p.Height <= 0 {
+		return nil, content, nil
+	}
+	if lines := strings.Split(output, "\n"); len(lines) > p.Height {
+		output = strings.Join(lines[len(lines)-p.Height:], "\n")
+	}
+	scr = NewScreen(p.Width, p.Height)
+	scr.LoadCapture(output)
+	scr.SetCursor(p.CursorY, p.CursorX)
+	return scr, content, nil
+}
+
+// SendKeys 將按鍵名稱（例如 "Enter"、"Escape"、"C-c"）送到指定目標（session 名稱或 pane id）。
+// 無法解讀為按鍵名稱的參數會被 tmux 當作一般文字送出。
+func (m *Manager) SendKeys(target string, keys ...string) error {
+	_, err := m.exec.Execute(append([]string{"send-keys", "-t", target}, keys...)...)
+	return err
+}
+
+// SendLiteral 以 send-keys -l 將文字原樣送到指定目標，不解讀按鍵名稱。
+func (m *Manager) SendLiteral(target, text string) error {
+	_, err := m.exec.Execute("send-keys", "-t", target, "-l", "--", text)
+	return err
+}
+
+// ListPanes 一次列出所有 session 的所有 pane。
+func (m *Manager) ListPanes() ([]Pane, error) {
+	output, err := m.exec.Execute("list-panes", "-a", "-F", ListPanesFormat)
+	if err != nil {
+		return nil, err
+	}
+	return ParseListPanes(output)
+}
+
+// PaneDetailsFormat 是傳給 tmux list-panes -a -F 的格式字串，取得重建 pane 所需的版面與目錄。
+const PaneDetailsFormat = "#{pane_id}\t#{window_layout}\t#{pane_current_path}"
+
+// PaneDetail 是輪詢用不到、但重建 session 時需要的 pane 資訊。
+type PaneDetail struct {
+	Layout string // 所屬 window 的 window_layout
+	Dir    string // pane_current_path
+}
+
+// PaneDetails 列出所有 pane 的版面與目前目錄，以 pane id 為鍵。
+func (m *Manager) PaneDetails() (map[string]PaneDetail, error) {
+	output, err := m.exec.Execute("list-panes", "-a", "-F", PaneDetailsFormat)
+	if err != nil {
+		return nil, err
+	}
+	details := make(map[string]PaneDetail)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("unexpected format: %q", line)
+		}
+		details[parts[0]] = PaneDetail{Layout: parts[1], Dir: parts[2]}
+	}
+	return details, nil
+}
@@ -48,6 +48,13 @@ func TestSession_StatusIcon(t *testing.T) {
 	}
 }
 
+func TestSessionStatus_String(t *testing.T) {
+	assert.Equal(t, "idle", tmux.StatusIdle.String())
+	assert.Equal(t, "running", tmux.StatusRunning.String())
+	assert.Equal(t, "waiting", tmux.StatusWaiting.String())
+	assert.Equal(t, "error", tmux.StatusError.String())
+}
+
 func TestParseListSessions(t *testing.T) {
 	output := `my-project:$1:1:/home/user/project:1:1709312400
 api-server:$2:0:/home/user/api:0:1709308800`
@@ -103,23 +110,21 @@ func TestManager_ListSessions(t *testing.T) {
 }
 
 func TestManager_KillSession(t *testing.T) {
-	mock := &mockExecutor{outputs: map[string]string{
-		"kill-session -t my-session": "",
-	}}
+	rec := &recordingExecutor{}
 
-	mgr := tmux.NewManager(mock)
+	mgr := tmux.NewManager(rec)
 	err := mgr.KillSession("my-session")
 	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"kill-session", "-t", "=my-session"}}, rec.calls)
 }
 
 func TestManager_RenameSession(t *testing.T) {
-	mock := &mockExecutor{outputs: map[string]string{
-		"rename-session -t old-name new-name": "",
-	}}
+	rec := &recordingExecutor{}
 
-	mgr := tmux.NewManager(mock)
+	mgr := tmux.NewManager(rec)
 	err := mgr.RenameSession("old-name", "new-name")
 	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"rename-session", "-t", "=old-name", "new-name"}}, rec.calls)
 }
 
 func TestManager_NewSession(t *testing.T) {
@@ -132,6 +137,14 @@ func TestManager_NewSession(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestValidateSessionName(t *testing.T) {
+	assert.NoError(t, tmux.ValidateSessionName("api-server_2"))
+	assert.Error(t, tmux.ValidateSessionName(""))
+	assert.Error(t, tmux.ValidateSessionName("  "))
+	assert.Error(t, tmux.ValidateSessionName("v1.2"))
+	assert.Error(t, tmux.ValidateSessionName("host:8080"))
+}
+
 func TestManager_CapturePane(t *testing.T) {
 	mock := &mockExecutor{outputs: map[string]string{
 		"capture-pane -t my-session -p -S -150": "line 1\nline 2\nline 3",
@@ -143,3 +156,167 @@ func TestManager_CapturePane(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "line 1\nline 2\nline 3", output)
 }
+
+func TestManager_CapturePaneANSI(t *testing.T) {
+	mock := &mockExecutor{outputs: map[string]string{
+		"capture-pane -t %1 -p -e -S -20": "\033[32mok\033[0m",
+	}}
+
+	output, err := tmux.NewManager(mock).CapturePaneANSI("%1", 20)
+	assert.NoError(t, err)
+	assert.Equal(t, "\033[32mok\033[0m", output)
+}
+
+func TestParseListPanes(t *testing.T) {
+	output := "work\t0\teditor\t0\t%1\t1001\tnvim\t1\t80\t24\t0\t0\tmain.go\n" +
+		"work\t1\tagents\t0\t%2\t1002\tclaude\t1\t120\t40\t2\t39\t⠋ Claude: fix: tests\n" +
+		"work\t1\tagents\t1\t%3\t1003\tnpm\t0\t80\t24\t0\t0\tdev server"
+
+	panes, err := tmux.ParseListPanes(output)
+	assert.NoError(t, err)
+	assert.Len(t, panes, 3)
+
+	assert.Equal(t, tmux.Pane{
+		ID: "%2", SessionName: "work", WindowIndex: 1, WindowName: "agents",
+		Index: 0, PID: 1002, Command: "claude", Title: "⠋ Claude: fix: tests", Active: true,
+		Width: 120, Height: 40, CursorX: 2, CursorY: 39,
+	}, panes[1])
+	assert.Equal(t, "%3", panes[2].ID)
+	assert.False(t, panes[2].Active)
+}
+
+func TestParseListPanes_Invalid(t *testing.T) {
+	_, err := tmux.ParseListPanes("work\t0\tonly-three")
+	assert.Error(t, err)
+}
+
+func TestGroupWindows(t *testing.T) {
+	panes := []tmux.Pane{
+		{ID: "%1", WindowIndex: 0, WindowName: "editor", Status: tmux.StatusIdle},
+		{ID: "%2", WindowIndex: 1, WindowName: "agents", Status: tmux.StatusRunning},
+		{ID: "%3", WindowIndex: 1, WindowName: "agents", Status: tmux.StatusWaiting},
+	}
+
+	windows := tmux.GroupWindows(panes)
+	assert.Len(t, windows, 2)
+	assert.Equal(t, "editor", windows[0].Name)
+	assert.Equal(t, tmux.StatusIdle, windows[0].Status)
+	assert.Len(t, windows[1].Panes, 2)
+	assert.Equal(t, tmux.StatusWaiting, windows[1].Status)
+}
+
+func TestManager_ListPanes(t *testing.T) {
+	mock := &mockExecutor{outputs: map[string]string{
+		"list-panes -a -F " + tmux.ListPanesFormat: "work\t0\tzsh\t0\t%1\t42\tzsh\t1\t80\t24\t0\t0\thost",
+	}}
+
+	panes, err := tmux.NewManager(mock).ListPanes()
+	assert.NoError(t, err)
+	assert.Len(t, panes, 1)
+	assert.Equal(t, "%1", panes[0].ID)
+}
+
+func TestManager_PaneSession(t *testing.T) {
+	mock := &mockExecutor{outputs: map[string]string{
+		"display-message -p -t %3 #{session_name}": "work",
+	}}
+
+	name, err := tmux.NewManager(mock).PaneSession("%3")
+	assert.NoError(t, err)
+	assert.Equal(t, "work", name)
+}
+
+func TestManager_PaneSessionID(t *testing.T) {
+	mock := &mockExecutor{outputs: map[string]string{
+		"display-message -p -t %3 #{session_id}:#{session_name}": "$2:work",
+	}}
+
+	id, name, err := tmux.NewManager(mock).PaneSessionID("%3")
+	assert.NoError(t, err)
+	assert.Equal(t, "$2", id)
+	assert.Equal(t, "work", name)
+
+	_, _, err = tmux.NewManager(&mockExecutor{}).PaneSessionID("%9")
+	assert.Error(t, err)
+}
+
+func TestSessionPane(t *testing.T) {
+	assert.Equal(t, "=api:", tmux.SessionPane("api"))
+}
+
+func TestManager_SwitchClient(t *testing.T) {
+	rec := &recordingExecutor{}
+
+	mgr := tmux.NewManager(rec)
+	assert.NoError(t, mgr.SwitchClient("work"))
+	assert.Equal(t, [][]string{{"switch-client", "-t", "=work"}}, rec.calls)
+}
+
+func TestManager_Attach_InsideTmux(t *testing.T) {
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1234,0")
+	rec := &recordingExecutor{}
+
+	mgr := tmux.NewManager(rec)
+	assert.NoError(t, mgr.Attach("work"))
+	assert.Equal(t, [][]string{{"switch-client", "-t", "=work"}}, rec.calls)
+}
+
+func TestInsidePopup(t *testing.T) {
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1234,0")
+	t.Setenv("TMUX_PANE", "%1")
+	assert.False(t, tmux.InsidePopup())
+
+	t.Setenv("TMUX_PANE", "")
+	assert.True(t, tmux.InsidePopup())
+
+	t.Setenv("TMUX", "")
+	assert.False(t, tmux.InsidePopup())
+}
+
+// recordingExecutor 記錄所有被執行的指令。
+type recordingExecutor struct {
+	calls [][]string
+}
+
+func (r *recordingExecutor) Execute(args ...string) (string, error) {
+	r.calls = append(r.calls, args)
+	return "", nil
+}
+
+func TestManager_SendKeys(t *testing.T) {
+	rec := &recordingExecutor{}
+	mgr := tmux.NewManager(rec)
+
+	assert.NoError(t, mgr.SendKeys("%3", "Escape"))
+	assert.NoError(t, mgr.SendLiteral("%3", "-y"))
+	assert.Equal(t, [][]string{
+		{"send-keys", "-t", "%3", "Escape"},
+		{"send-keys", "-t", "%3", "-l", "--", "-y"},
+	}, rec.calls)
+}
+
+func TestSession_TargetPane(t *testing.T) {
+	s := tmux.Session{Name: "work", Windows: []tmux.Window{
+		{Panes: []tmux.Pane{{ID: "%1", Active: true}, {ID: "%2", Status: tmux.StatusWaiting, Wait: tmux.WaitInfo{Reason: tmux.ReasonPrompt}}}},
+		{Panes: []tmux.Pane{{ID: "%3", Status: tmux.StatusWaiting, Wait: tmux.WaitInfo{Reason: tmux.ReasonPermission}}}},
+	}}
+	assert.Equal(t, "%3", s.TargetPane())
+
+	s.Windows = s.Windows[:1]
+	s.Windows[0].Panes[1].Status = tmux.StatusIdle
+	assert.Equal(t, "%1", s.TargetPane())
+
+	assert.Equal(t, "work", tmux.Session{Name: "work"}.TargetPane())
+}
+
+func TestManager_PaneDetails(t *testing.T) {
+	mock := &mockExecutor{outputs: map[string]string{
+		"list-panes -a -F " + tmux.PaneDetailsFormat: "%1\tb25d,80x24,0,0,1\t/src/api\n%2\tc3a1,80x24,0,0{40x24,0,0,2,39x24,41,0,3}\t/home/me/my project",
+	}}
+	details, err := tmux.NewManager(mock).PaneDetails()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]tmux.PaneDetail{
+		"%1": {Layout: "b25d,80x24,0,0,1", Dir: "/src/api"},
+		"%2": {Layout: "c3a1,80x24,0,0{40x24,0,0,2,39x24,41,0,3}", Dir: "/home/me/my project"},
+	}, details)
+}
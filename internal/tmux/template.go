@@ -0,0 +1,160 @@
+package tmux
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Split 是 pane 的分割方向。
+type Split string
+
+const (
+	SplitVertical   Split = "vertical"   // 上下分割（split-window -v，預設）
+	SplitHorizontal Split = "horizontal" // 左右分割（split-window -h）
+)
+
+// Template 是可重複建立的 session 版面：依序建立的 window 與各 window 中的 pane。
+type Template struct {
+	Name    string
+	Env     map[string]string // 套用到所有 pane 的環境變數
+	Windows []TemplateWindow
+}
+
+// TemplateWindow 是範本中的一個 window。
+type TemplateWindow struct {
+	Name   string
+	Dir    string            // 工作目錄，相對路徑以 session 目錄為基準
+	Layout string            // 建立所有 pane 後套用的 select-layout（例如 main-vertical），空字串表示不調整
+	Env    map[string]string // 覆蓋範本的環境變數
+	Panes  []TemplatePane    // 沒有 pane 時建立一個不執行指令的 pane
+}
+
+// TemplatePane 是 window 中的一個 pane。第一個 pane 隨 window 建立，
+// 其餘依序從前一個 pane 分割出來。
+type TemplatePane struct {
+	Split   Split             // 分割方向，第一個 pane 忽略
+	Size    string            // 分割後的大小，例如 "30%" 或 "20"（列或欄），空字串表示平分
+	Dir     string            // 工作目錄，相對路徑以 window 目錄為基準
+	Command string            // 建立後送出的指令
+	Env     map[string]string // 覆蓋 window 的環境變數
+}
+
+// templatePaneFormat 讓 new-session、new-window 與 split-window 印出新 pane 的 id。
+const templatePaneFormat = "#{pane_id}"
+
+// NewSessionFromTemplate 以範本建立 detached session：第一個 window 隨 new-session 建立，
+// 其餘以 new-window 建立，pane 以 split-window 分割，最後送出各 pane 的指令。
+// 新的 window 與 pane 都以 -d 建立，因此第一個 window 的第一個 pane 維持為目前的 pane。
+// session 建立後任一步驟失敗時會刪除該 session，不留下建立到一半的版面。
+func (m *Manager) NewSessionFromTemplate(name, dir string, t Template) (err error) {
+	created := false
+	defer func() {
+		if err != nil && created {
+			m.exec.Execute("kill-session", "-t", exactSession(name))
+		}
+	}()
+
+	windows := t.Windows
+	if len(windows) == 0 {
+		windows = []TemplateWindow{{}}
+	}
+
+	type pending struct {
+		pane    string
+		command string
+	}
+	var commands []pending
+	for wi, w := range windows {
+		panes := w.Panes
+		if len(panes) == 0 {
+			panes = []TemplatePane{{}}
+		}
+		windowDir := resolveDir(dir, w.Dir)
+
+		var prev string
+		for pi, p := range panes {
+			args := []string{"new-window", "-d", "-t", name + ":", "-P", "-F", templatePaneFormat}
+			switch {
+			case wi == 0 && pi == 0:
+				args = []string{"new-session", "-d", "-s", name, "-P", "-F", templatePaneFormat}
+			case pi > 0:
+				args = []string{"split-window", "-d", "-t", prev, "-P", "-F", templatePaneFormat, splitFlag(p.Split)}
+				if p.Size != "" {
+					args = append(args, "-l", p.Size)
+				}
+			}
+			if pi == 0 && w.Name != "" {
+				args = append(args, "-n", w.Name)
+			}
+			args = append(args, "-c", resolveDir(windowDir, p.Dir))
+			args = append(args, envArgs(t.Env, w.Env, p.Env)...)
+
+			out, err := m.exec.Execute(args...)
+			if err != nil {
+				return fmt.Errorf("template %s: window %d pane %d: %w", t.Name, wi, pi, err)
+			}
+			created = true
+			prev = strings.TrimSpace(out)
+			if p.Command != "" {
+				commands = append(commands, pending{pane: prev, command: p.Command})
+			}
+		}
+		if w.Layout != "" {
+			if _, err := m.exec.Execute("select-layout", "-t", prev, w.Layout); err != nil {
+				return fmt.Errorf("template %s: window %d layout: %w", t.Name, wi, err)
+			}
+		}
+	}
+
+	for _, c := range commands {
+		if err := m.SendLiteral(c.pane, c.command); err != nil {
+			return err
+		}
+		if err := m.SendKeys(c.pane, "Enter"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitFlag 回傳分割方向對應的 split-window 參數。
+func splitFlag(s Split) string {
+	if s == SplitHorizontal {
+		return "-h"
+	}
+	return "-v"
+}
+
+// resolveDir 以 base 為基準解析 dir；dir 為空時回傳 base。
+func resolveDir(base, dir string) string {
+	switch {
+	case dir == "":
+		return base
+	case filepath.IsAbs(dir):
+		return dir
+	default:
+		return filepath.Join(base, dir)
+	}
+}
+
+// envArgs 依序合併環境變數（後者覆蓋前者），回傳依名稱排序的 -e 參數。
+func envArgs(layers ...map[string]string) []string {
+	env := make(map[string]string)
+	for _, layer := range layers {
+		for k, v := range layer {
+			env[k] = v
+		}
+	}
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	args := make([]string, 0, 2*len(keys))
+	for _, k := range keys {
+		args = append(args, "-e", k+"="+env[k])
+	}
+	return args
+}
@@ -0,0 +1,121 @@
+package tmux_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+)
+
+// paneExecutor 記錄指令，並為每個建立 pane 的指令（含 -P）回傳遞增的 pane id。
+// 指令以 fail 開頭時回傳錯誤。
+type paneExecutor struct {
+	calls []string
+	next  int
+	fail  string
+}
+
+func (e *paneExecutor) Execute(args ...string) (string, error) {
+	call := strings.Join(args, " ")
+	e.calls = append(e.calls, call)
+	if e.fail != "" && strings.HasPrefix(call, e.fail) {
+		return "", fmt.Errorf("%s failed", args[0])
+	}
+	for _, a := range args {
+		if a == "-P" {
+			e.next++
+			return fmt.Sprintf("%%%d\n", e.next), nil
+		}
+	}
+	return "", nil
+}
+
+func TestManager_NewSessionFromTemplate(t *testing.T) {
+	exec := &paneExecutor{}
+	mgr := tmux.NewManager(exec)
+
+	tpl := tmux.Template{
+		Name: "dev",
+		Env:  map[string]string{"APP_ENV": "dev"},
+		Windows: []tmux.TemplateWindow{
+			{
+				Name:   "code",
+				Layout: "main-vertical",
+				Panes: []tmux.TemplatePane{
+					{Command: "nvim"},
+					{Split: tmux.SplitHorizontal, Size: "40%", Command: "claude", Env: map[string]string{"APP_ENV": "ai"}},
+				},
+			},
+			{
+				Name: "server",
+				Dir:  "web",
+				Env:  map[string]string{"PORT": "3000"},
+				Panes: []tmux.TemplatePane{
+					{Command: "npm run dev"},
+					{Dir: "/var/log", Command: "tail -f app.log"},
+				},
+			},
+		},
+	}
+	require.NoError(t, mgr.NewSessionFromTemplate("proj", "/src/proj", tpl))
+
+	assert.Equal(t, []string{
+		"new-session -d -s proj -P -F #{pane_id} -n code -c /src/proj -e APP_ENV=dev",
+		"split-window -d -t %1 -P -F #{pane_id} -h -l 40% -c /src/proj -e APP_ENV=ai",
+		"select-layout -t %2 main-vertical",
+		"new-window -d -t proj: -P -F #{pane_id} -n server -c /src/proj/web -e APP_ENV=dev -e PORT=3000",
+		"split-window -d -t %3 -P -F #{pane_id} -v -c /var/log -e APP_ENV=dev -e PORT=3000",
+		"send-keys -t %1 -l -- nvim",
+		"send-keys -t %1 Enter",
+		"send-keys -t %2 -l -- claude",
+		"send-keys -t %2 Enter",
+		"send-keys -t %3 -l -- npm run dev",
+		"send-keys -t %3 Enter",
+		"send-keys -t %4 -l -- tail -f app.log",
+		"send-keys -t %4 Enter",
+	}, exec.calls)
+}
+
+func TestManager_NewSessionFromTemplate_Empty(t *testing.T) {
+	exec := &paneExecutor{}
+	mgr := tmux.NewManager(exec)
+
+	require.NoError(t, mgr.NewSessionFromTemplate("proj", "/src/proj", tmux.Template{Name: "empty"}))
+	assert.Equal(t, []string{"new-session -d -s proj -P -F #{pane_id} -c /src/proj"}, exec.calls)
+}
+
+func TestManager_NewSessionFromTemplate_FailureKillsSession(t *testing.T) {
+	exec := &paneExecutor{fail: "new-window"}
+	mgr := tmux.NewManager(exec)
+
+	tpl := tmux.Template{
+		Name: "dev",
+		Windows: []tmux.TemplateWindow{
+			{Name: "code", Panes: []tmux.TemplatePane{{Command: "nvim"}, {Command: "claude"}}},
+			{Name: "server"},
+		},
+	}
+	err := mgr.NewSessionFromTemplate("proj", "/src/proj", tpl)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "window 1 pane 0")
+
+	// 失敗前不送出任何指令，並刪除建立到一半的 session
+	assert.Equal(t, []string{
+		"new-session -d -s proj -P -F #{pane_id} -n code -c /src/proj",
+		"split-window -d -t %1 -P -F #{pane_id} -v -c /src/proj",
+		"new-window -d -t proj: -P -F #{pane_id} -n server -c /src/proj",
+		"kill-session -t =proj",
+	}, exec.calls)
+}
+
+func TestManager_NewSessionFromTemplate_NewSessionFails(t *testing.T) {
+	exec := &paneExecutor{fail: "new-session"}
+	mgr := tmux.NewManager(exec)
+
+	// session 沒有建立成功時不可刪除同名的既有 session
+	require.Error(t, mgr.NewSessionFromTemplate("proj", "/src/proj", tmux.Template{Name: "empty"}))
+	assert.Equal(t, []string{"new-session -d -s proj -P -F #{pane_id} -c /src/proj"}, exec.calls)
+}
@@ -0,0 +1,153 @@
+package tmux
+
+import (
+	"sync"
+	"time"
+)
+
+// maxTransitions 是 StatusTracker 保留的最近轉換筆數。
+const maxTransitions = 256
+
+// TrackerKey 是 StatusTracker 的追蹤鍵：session 中的某個 pane。
+// Session 為 session id，重新命名後仍是同一個鍵。
+type TrackerKey struct {
+	Session string
+	Pane    string
+}
+
+// Transition 記錄一次回報狀態的轉換。
+type Transition struct {
+	Key    TrackerKey
+	From   SessionStatus
+	To     SessionStatus
+	Source StatusSource // 造成轉換的偵測層（轉換到 StatusEnded 時為空）
+	At     time.Time
+}
+
+// trackedStatus 是單一追蹤鍵目前回報的狀態。
+type trackedStatus struct {
+	status   SessionStatus
+	since    time.Time // 目前狀態開始的時間
+	lastSeen time.Time // 最後一次實際偵測到目前狀態的時間
+}
+
+// StatusTracker 在多次輪詢之間追蹤各 pane 的狀態並套用防抖動規則，
+// 避免 spinner 換幀之間短暫看似閒置而造成狀態閃爍。
+//
+// 規則：轉換到優先順序較高的狀態（見 RollupStatus）立即生效；
+// 轉換到較低的狀態時，目前狀態必須已連續 hold[目前狀態] 未被偵測到才會生效。
+// 例如 running 的 hold 為 4 秒時，running → idle 需 4 秒內都偵測不到 running，
+// 而 running → waiting 會立即回報。可同時由多個 goroutine 使用。
+type StatusTracker struct {
+	mu          sync.Mutex
+	hold        map[SessionStatus]time.Duration
+	entries     map[TrackerKey]*trackedStatus
+	transitions []Transition
+	pending     []Transition // 尚未被 Drain 取走的轉換
+}
+
+// NewStatusTracker 建立 StatusTracker，hold 為各狀態消失後仍維持的時間（未設定者為 0）。
+func NewStatusTracker(hold map[SessionStatus]time.Duration) *StatusTracker {
+	return &StatusTracker{hold: hold, entries: make(map[TrackerKey]*trackedStatus)}
+}
+
+// Update 以 at 時由 source 偵測到的狀態更新 key，回傳套用防抖動規則後應回報的狀態。
+// 第一次出現的 key 直接採用偵測結果，不記錄為轉換。
+func (t *StatusTracker) Update(key TrackerKey, observed SessionStatus, source StatusSource, at time.Time) SessionStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.entries[key]
+	if !ok {
+		t.entries[key] = &trackedStatus{status: observed, since: at, lastSeen: at}
+		return observed
+	}
+	if observed == e.status {
+		e.lastSeen = at
+		return e.status
+	}
+	if statusPrecedence[observed] > statusPrecedence[e.status] || at.Sub(e.lastSeen) >= t.hold[e.status] {
+		t.transition(key, e, observed, source, at)
+	}
+	return e.status
+}
+
+// Set 不經防抖動直接設定 key 的狀態，用於 hook 這類確定的來源。
+func (t *StatusTracker) Set(key TrackerKey, status SessionStatus, source StatusSource, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.entries[key]
+	if !ok {
+		t.entries[key] = &trackedStatus{status: status, since: at, lastSeen: at}
+		return
+	}
+	if e.status == status {
+		e.lastSeen = at
+		return
+	}
+	t.transition(key, e, status, source, at)
+}
+
+// transition 切換狀態並記錄轉換，呼叫前須持有鎖。
+func (t *StatusTracker) transition(key TrackerKey, e *trackedStatus, to SessionStatus, source StatusSource, at time.Time) {
+	tr := Transition{Key: key, From: e.status, To: to, Source: source, At: at}
+	t.transitions = appendBounded(t.transitions, tr)
+	t.pending = appendBounded(t.pending, tr)
+	e.status, e.since, e.lastSeen = to, at, at
+}
+
+// Status 回傳 key 目前回報的狀態與開始時間；未追蹤的 key 回傳 ok = false。
+func (t *StatusTracker) Status(key TrackerKey) (status SessionStatus, since time.Time, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.entries[key]
+	if !ok {
+		return StatusIdle, time.Time{}, false
+	}
+	return e.status, e.since, true
+}
+
+// Transitions 回傳最近的狀態轉換（由舊到新，最多 maxTransitions 筆）。
+func (t *StatusTracker) Transitions() []Transition {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Transition(nil), t.transitions...)
+}
+
+// Drain 取出上次呼叫後新增的轉換（最多 maxTransitions 筆），例如寫入歷史紀錄。
+func (t *StatusTracker) Drain() []Transition {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pending := t.pending
+	t.pending = nil
+	return pending
+}
+
+// appendBounded 附加一筆轉換，超過 maxTransitions 時捨棄最舊的紀錄。
+func appendBounded(list []Transition, tr Transition) []Transition {
+	list = append(list, tr)
+	if n := len(list); n > maxTransitions {
+		list = append([]Transition(nil), list[n-maxTransitions:]...)
+	}
+	return list
+}
+
+// Retain 移除不在 keys 中的追蹤鍵（例如已關閉的 pane），
+// 並為每個被移除的鍵記錄一筆在 at 時轉換到 StatusEnded 的轉換，讓歷史紀錄的區間得以結束。
+func (t *StatusTracker) Retain(keys []TrackerKey, at time.Time) {
+	keep := make(map[TrackerKey]bool, len(keys))
+	for _, k := range keys {
+		keep[k] = true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for k := range t.entries {
+		if !keep[k] {
+			t.transition(k, t.entries[k], StatusEnded, "", at)
+			delete(t.entries, k)
+		}
+	}
+}
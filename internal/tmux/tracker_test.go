@@ -0,0 +1,102 @@
+package tmux_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+)
+
+var paneKey = tmux.TrackerKey{Session: "w", Pane: "%1"}
+
+func newTestTracker() *tmux.StatusTracker {
+	return tmux.NewStatusTracker(map[tmux.SessionStatus]time.Duration{
+		tmux.StatusRunning: 4 * time.Second,
+	})
+}
+
+func TestStatusTracker_HoldsRunning(t *testing.T) {
+	tr := newTestTracker()
+	t0 := time.Unix(1000, 0)
+
+	assert.Equal(t, tmux.StatusRunning, tr.Update(paneKey, tmux.StatusRunning, tmux.SourceContent, t0))
+	// spinner 換幀之間短暫看似閒置
+	assert.Equal(t, tmux.StatusRunning, tr.Update(paneKey, tmux.StatusIdle, tmux.SourceContent, t0.Add(2*time.Second)))
+	assert.Equal(t, tmux.StatusRunning, tr.Update(paneKey, tmux.StatusRunning, tmux.SourceContent, t0.Add(3*time.Second)))
+	assert.Equal(t, tmux.StatusRunning, tr.Update(paneKey, tmux.StatusIdle, tmux.SourceContent, t0.Add(6*time.Second)))
+	// running 已連續 4 秒未出現
+	assert.Equal(t, tmux.StatusIdle, tr.Update(paneKey, tmux.StatusIdle, tmux.SourceContent, t0.Add(7*time.Second)))
+
+	status, since, ok := tr.Status(paneKey)
+	assert.True(t, ok)
+	assert.Equal(t, tmux.StatusIdle, status)
+	assert.Equal(t, t0.Add(7*time.Second), since)
+	assert.Equal(t, []tmux.Transition{
+		{Key: paneKey, From: tmux.StatusRunning, To: tmux.StatusIdle, Source: tmux.SourceContent, At: t0.Add(7 * time.Second)},
+	}, tr.Transitions())
+	assert.Len(t, tr.Drain(), 1)
+	assert.Empty(t, tr.Drain())
+}
+
+func TestStatusTracker_EscalatesImmediately(t *testing.T) {
+	tr := newTestTracker()
+	t0 := time.Unix(1000, 0)
+
+	tr.Update(paneKey, tmux.StatusIdle, tmux.SourceContent, t0)
+	assert.Equal(t, tmux.StatusRunning, tr.Update(paneKey, tmux.StatusRunning, tmux.SourceContent, t0.Add(time.Second)))
+	assert.Equal(t, tmux.StatusWaiting, tr.Update(paneKey, tmux.StatusWaiting, tmux.SourceContent, t0.Add(2*time.Second)))
+	// waiting 沒有設定維持時間，回到 running 立即生效
+	assert.Equal(t, tmux.StatusRunning, tr.Update(paneKey, tmux.StatusRunning, tmux.SourceContent, t0.Add(3*time.Second)))
+	assert.Len(t, tr.Transitions(), 3)
+}
+
+func TestStatusTracker_SetBypassesHold(t *testing.T) {
+	tr := newTestTracker()
+	t0 := time.Unix(1000, 0)
+
+	tr.Update(paneKey, tmux.StatusRunning, tmux.SourceContent, t0)
+	tr.Set(paneKey, tmux.StatusIdle, tmux.SourceHook, t0.Add(time.Second))
+
+	status, _, _ := tr.Status(paneKey)
+	assert.Equal(t, tmux.StatusIdle, status)
+}
+
+func TestStatusTracker_Retain(t *testing.T) {
+	tr := newTestTracker()
+	t0 := time.Unix(1000, 0)
+	tr.Update(tmux.TrackerKey{Session: "a", Pane: "%1"}, tmux.StatusRunning, tmux.SourceContent, t0)
+	tr.Update(tmux.TrackerKey{Session: "b", Pane: "%2"}, tmux.StatusRunning, tmux.SourceContent, t0)
+
+	tr.Retain([]tmux.TrackerKey{{Session: "a", Pane: "%1"}}, t0.Add(time.Minute))
+
+	_, _, ok := tr.Status(tmux.TrackerKey{Session: "b", Pane: "%2"})
+	assert.False(t, ok)
+	_, _, ok = tr.Status(tmux.TrackerKey{Session: "a", Pane: "%1"})
+	assert.True(t, ok)
+
+	// 消失的 pane 以轉換到 ended 結束
+	assert.Equal(t, []tmux.Transition{{
+		Key:  tmux.TrackerKey{Session: "b", Pane: "%2"},
+		From: tmux.StatusRunning,
+		To:   tmux.StatusEnded,
+		At:   t0.Add(time.Minute),
+	}}, tr.Drain())
+}
+
+func TestStatusTracker_TransitionsBounded(t *testing.T) {
+	tr := tmux.NewStatusTracker(nil)
+	t0 := time.Unix(1000, 0)
+	for i := 0; i < 300; i++ {
+		status := tmux.StatusIdle
+		if i%2 == 1 {
+			status = tmux.StatusRunning
+		}
+		tr.Update(paneKey, status, tmux.SourceContent, t0.Add(time.Duration(i)*time.Second))
+	}
+
+	transitions := tr.Transitions()
+	assert.Len(t, transitions, 256)
+	assert.Equal(t, t0.Add(299*time.Second), transitions[len(transitions)-1].At, fmt.Sprint(transitions[len(transitions)-1]))
+}
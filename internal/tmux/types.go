@@ -13,20 +13,93 @@ const (
 	StatusRunning                      // AI 正在工作
 	StatusWaiting                      // 等待人類輸入
 	StatusError                        // 錯誤
+	StatusEnded                        // pane 已關閉，只出現在狀態轉換紀錄中
 )
 
+// String 回傳狀態名稱，與 hook 狀態檔案中的 status 欄位一致。
+func (s SessionStatus) String() string {
+	switch s {
+	case StatusRunning:
+		return "running"
+	case StatusWaiting:
+		return "waiting"
+	case StatusError:
+		return "error"
+	case StatusEnded:
+		return "ended"
+	default:
+		return "idle"
+	}
+}
+
+// Pane 代表 tmux 的一個 pane。
+type Pane struct {
+	ID          string // pane id，例如 "%3"
+	SessionName string
+	WindowIndex int
+	WindowName  string
+	Index       int    // pane index
+	PID         int    // pane 內第一個程序的 pid
+	Command     string // 目前執行的指令（pane_current_command）
+	Title       string
+	Active      bool // 是否為該 window 的 active pane
+	Width       int  // pane 寬度（欄）
+	Height      int  // pane 高度（列）
+	CursorX     int  // 游標所在欄
+	CursorY     int  // 游標所在列
+	Status      SessionStatus
+	StatusSince time.Time    // 目前狀態開始的時間（未追蹤時為零值）
+	Source      StatusSource // 決定狀態的偵測層
+	Wait        WaitInfo     // 等待原因（Status 為 StatusWaiting 時）
+	Agent       string       // 偵測到的 agent 名稱（空字串表示一般 shell 或無法判斷）
+	AIModel     string
+}
+
+// Window 代表 tmux 的一個 window 及其 pane。
+type Window struct {
+	Index  int
+	Name   string
+	Panes  []Pane
+	Status SessionStatus // 由 pane 狀態彙整而來
+}
+
 // Session 代表一個 tmux session。
 type Session struct {
-	Name      string
-	ID        string        // tmux session id
-	Path      string        // 工作目錄
-	Attached  bool
-	Activity  time.Time     // 最後活動時間
-	Status    SessionStatus
-	AIModel   string        // 偵測到的 AI 模型（空字串表示非 AI session）
-	AISummary string        // AI 摘要
-	GroupName string        // 所屬群組
-	SortOrder int           // 排序順序
+	Name       string
+	CustomName string // 使用者自訂的顯示名稱
+	ID         string // tmux session id
+	Path       string // 工作目錄
+	Attached   bool
+	Activity   time.Time // 最後活動時間
+	Status     SessionStatus
+	Wait       WaitInfo // 各 pane 中最需要處理的等待原因
+	AIModel    string   // 偵測到的 AI 模型（空字串表示非 AI session）
+	Agent      string   // 偵測到的 agent 名稱，例如 "claude-code"
+	AISummary  string   // AI 摘要
+	GroupName  string   // 所屬群組
+	SortOrder  int      // 排序順序
+	Windows    []Window // window 與 pane（Status 由其彙整而來）
+}
+
+// TargetPane 回傳操作 session 時應送往的 pane id：等待原因最急迫的 pane 優先，
+// 其次是第一個 active pane。沒有 pane 資訊時回傳 session 名稱（由 tmux 選擇 active pane）。
+func (s Session) TargetPane() string {
+	target, best := s.Name, 0
+	for _, w := range s.Windows {
+		for _, p := range w.Panes {
+			rank := 0
+			switch {
+			case p.Status == StatusWaiting:
+				rank = 2 + waitUrgency[p.Wait.Reason]
+			case p.Active:
+				rank = 1
+			}
+			if rank > best {
+				target, best = p.ID, rank
+			}
+		}
+	}
+	return target
 }
 
 // RelativeTime 回傳相對於現在的時間字串（例如 "30s", "5m", "3h", "2d"）。
@@ -64,3 +137,9 @@ func (s Session) StatusIcon() string {
 type Executor interface {
 	Execute(args ...string) (string, error)
 }
+
+// RawExecutor 是可回傳未修剪輸出的 Executor（選用介面）。
+// Execute 會去除輸出前後的空白，但 capture-pane 開頭與結尾的空白列也代表畫面位置。
+type RawExecutor interface {
+	ExecuteRaw(args ...string) (string, error)
+}
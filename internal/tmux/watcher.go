@@ -0,0 +1,110 @@
+package tmux
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// HookUpdate 是狀態目錄中單一 session 狀態檔案的變更。
+type HookUpdate struct {
+	SessionID string
+	Status    *HookStatus // nil 表示狀態檔案已被刪除
+}
+
+// HookWatcher 監聽 hook 狀態目錄（Linux 上透過 inotify），
+// 在狀態檔案建立、改寫或刪除時送出 HookUpdate，讓第一層狀態不必等下一次輪詢。
+type HookWatcher struct {
+	dir     string
+	fsw     *fsnotify.Watcher
+	updates chan HookUpdate
+	done    chan struct{}
+}
+
+// NewHookWatcher 開始監聽 statusDir，目錄不存在時會自動建立。
+func NewHookWatcher(statusDir string) (*HookWatcher, error) {
+	if err := os.MkdirAll(statusDir, 0755); err != nil {
+		return nil, fmt.Errorf("create status dir: %w", err)
+	}
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create watcher: %w", err)
+	}
+	if err := fsw.Add(statusDir); err != nil {
+		fsw.Close()
+		return nil, fmt.Errorf("watch %s: %w", statusDir, err)
+	}
+
+	w := &HookWatcher{
+		dir:     statusDir,
+		fsw:     fsw,
+		updates: make(chan HookUpdate),
+		done:    make(chan struct{}),
+	}
+	go w.loop()
+	return w, nil
+}
+
+// Updates 回傳狀態變更的 channel，Close 之後關閉。
+func (w *HookWatcher) Updates() <-chan HookUpdate {
+	return w.updates
+}
+
+// Close 停止監聽。
+func (w *HookWatcher) Close() error {
+	close(w.done)
+	return w.fsw.Close()
+}
+
+func (w *HookWatcher) loop() {
+	defer close(w.updates)
+	for {
+		select {
+		case <-w.done:
+			return
+		case ev, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			update, ok := w.handle(ev)
+			if !ok {
+				continue
+			}
+			select {
+			case w.updates <- update:
+			case <-w.done:
+				return
+			}
+		case _, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// handle 將檔案系統事件轉為 HookUpdate。暫存檔（以 . 開頭）與無法解析的檔案會被略過。
+func (w *HookWatcher) handle(ev fsnotify.Event) (HookUpdate, bool) {
+	id := filepath.Base(ev.Name)
+	if strings.HasPrefix(id, ".") {
+		return HookUpdate{}, false
+	}
+	if !ev.Has(fsnotify.Create) && !ev.Has(fsnotify.Write) &&
+		!ev.Has(fsnotify.Remove) && !ev.Has(fsnotify.Rename) {
+		return HookUpdate{}, false
+	}
+
+	hs, err := ReadHookStatus(w.dir, id)
+	if errors.Is(err, fs.ErrNotExist) {
+		return HookUpdate{SessionID: id}, true
+	}
+	if err != nil {
+		return HookUpdate{}, false
+	}
+	return HookUpdate{SessionID: id, Status: &hs}, true
+}
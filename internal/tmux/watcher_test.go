@@ -0,0 +1,61 @@
+package tmux_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+)
+
+// nextUpdate 等待指定 session 的下一個更新。
+func nextUpdate(t *testing.T, w *tmux.HookWatcher, session string) tmux.HookUpdate {
+	t.Helper()
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case u := <-w.Updates():
+			if u.SessionID == session {
+				return u
+			}
+		case <-timeout:
+			t.Fatalf("no update for %s", session)
+		}
+	}
+}
+
+func TestHookWatcher(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "status")
+	w, err := tmux.NewHookWatcher(dir)
+	require.NoError(t, err)
+	defer w.Close()
+
+	// 建立
+	require.NoError(t, tmux.WriteHookStatus(dir, "work", tmux.NewHookStatus("UserPromptSubmit")))
+	u := nextUpdate(t, w, "work")
+	require.NotNil(t, u.Status)
+	assert.Equal(t, tmux.StatusRunning, u.Status.Status)
+
+	// 改寫
+	require.NoError(t, tmux.WriteHookStatus(dir, "work", tmux.NewHookStatus("PermissionRequest")))
+	u = nextUpdate(t, w, "work")
+	require.NotNil(t, u.Status)
+	assert.Equal(t, tmux.StatusWaiting, u.Status.Status)
+
+	// 刪除
+	require.NoError(t, os.Remove(filepath.Join(dir, "work")))
+	u = nextUpdate(t, w, "work")
+	assert.Nil(t, u.Status)
+}
+
+func TestHookWatcher_Close(t *testing.T) {
+	w, err := tmux.NewHookWatcher(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	_, ok := <-w.Updates()
+	assert.False(t, ok)
+}
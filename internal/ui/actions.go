@@ -0,0 +1,112 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+)
+
+// quickAction 是不必連線到 session 就能執行的操作。
+type quickAction int
+
+const (
+	actionApprove quickAction = iota // 同意權限、計畫或問題
+	actionDeny                       // 拒絕
+	actionReply                      // 輸入一段文字並送出
+)
+
+func (a quickAction) String() string {
+	switch a {
+	case actionApprove:
+		return "approve"
+	case actionDeny:
+		return "deny"
+	default:
+		return "reply"
+	}
+}
+
+// actionReasons 是各操作預期的等待原因，不符合時需要使用者確認才送出。
+var actionReasons = map[quickAction][]tmux.WaitReason{
+	actionApprove: {tmux.ReasonPermission, tmux.ReasonPlan, tmux.ReasonQuestion},
+	actionDeny:    {tmux.ReasonPermission, tmux.ReasonPlan, tmux.ReasonQuestion},
+	actionReply:   {tmux.ReasonPrompt, tmux.ReasonQuestion},
+}
+
+// keystroke 是一次 send-keys：Text 以 -l 原樣送出，否則送出按鍵名稱 Key。
+type keystroke struct {
+	Text string
+	Key  string
+}
+
+// actionKeys 回傳操作在指定等待原因下要送出的按鍵。
+// 權限與計畫確認選單的預設選項即為同意，Esc 為拒絕；Y/n 問題則輸入 y 或 n。
+func actionKeys(a quickAction, reason tmux.WaitReason, reply string) []keystroke {
+	enter := keystroke{Key: "Enter"}
+	switch {
+	case a == actionReply:
+		return []keystroke{{Text: reply}, enter}
+	case reason == tmux.ReasonQuestion && a == actionApprove:
+		return []keystroke{{Text: "y"}, enter}
+	case reason == tmux.ReasonQuestion:
+		return []keystroke{{Text: "n"}, enter}
+	case a == actionApprove:
+		return []keystroke{enter}
+	default:
+		return []keystroke{{Key: "Escape"}}
+	}
+}
+
+// pendingAction 是即將送出的操作。
+type pendingAction struct {
+	action  quickAction
+	session string
+	target  string // pane id 或 session 名稱
+	reason  tmux.WaitReason
+	reply   string
+}
+
+// newPendingAction 建立對 session 的操作，目標為等待原因最急迫的 pane。
+func newPendingAction(a quickAction, s tmux.Session, reply string) pendingAction {
+	return pendingAction{action: a, session: s.Name, target: s.TargetPane(), reason: s.Wait.Reason, reply: reply}
+}
+
+// expected 回傳 session 目前的等待原因是否符合此操作。
+func (p pendingAction) expected() bool {
+	return slices.Contains(actionReasons[p.action], p.reason)
+}
+
+// confirmText 回傳等待原因不符時的確認訊息。
+func (p pendingAction) confirmText() string {
+	reason := string(p.reason)
+	if reason == "" {
+		reason = "not waiting"
+	}
+	return fmt.Sprintf("%s 目前為 %s，仍要送出 %s？[y/N]", p.session, reason, p.action)
+}
+
+// sentMsg 是操作送出後的結果。
+type sentMsg struct {
+	action pendingAction
+	err    error
+}
+
+// sendActionCmd 在背景依序送出操作的按鍵。
+func sendActionCmd(mgr *tmux.Manager, p pendingAction) tea.Cmd {
+	return func() tea.Msg {
+		for _, k := range actionKeys(p.action, p.reason, p.reply) {
+			var err error
+			if k.Text != "" {
+				err = mgr.SendLiteral(p.target, k.Text)
+			} else {
+				err = mgr.SendKeys(p.target, k.Key)
+			}
+			if err != nil {
+				return sentMsg{action: p, err: fmt.Errorf("%s %s: %w", p.action, p.session, err)}
+			}
+		}
+		return sentMsg{action: p}
+	}
+}
@@ -3,29 +3,76 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/wake/tmux-session-menu/internal/config"
+	"github.com/wake/tmux-session-menu/internal/store"
 	"github.com/wake/tmux-session-menu/internal/tmux"
 )
 
+// Deps 是 Model 所依賴的外部元件。TmuxMgr 為 nil 時不啟動輪詢（方便測試）。
+type Deps struct {
+	TmuxMgr *tmux.Manager
+	Store   *store.Store
+	Cfg     config.Config
+	Events  <-chan tmux.Notification // control mode 通知（可為 nil，僅靠輪詢）
+	Hooks   <-chan tmux.HookUpdate   // hook 狀態檔案變更（可為 nil，僅靠輪詢）
+	Tracker *tmux.StatusTracker      // 跨輪詢的狀態防抖動（可為 nil，不做防抖動）
+}
+
 // Model 是 Bubble Tea 的主要模型。
 type Model struct {
+	deps     Deps
 	width    int
 	height   int
 	cursor   int
 	items    []ListItem
+	groups   []store.Group
+	sessions []tmux.Session
+	err      error
 	quitting bool
+	selected string // 離開時要連線的 session
+	notice   string // 最近一次操作的結果
+
+	reply   *lineInput      // 正在輸入回覆文字（nil 表示未輸入）
+	search  *lineInput      // 正在輸入搜尋字串（nil 表示未搜尋）
+	pending *pendingAction  // 等待原因不符、等待使用者確認的操作
+	form    *newSessionForm // 正在填寫新建 session 表單（nil 表示未開啟）
+
+	split          int                // 並排模式下列表所佔寬度的百分比
+	previews       map[string]preview // session 名稱 → 最近一次擷取的預覽
+	previewLoading map[string]bool    // 正在背景擷取預覽的 session
+
+	refreshPending bool // 已收到 tmux 通知、等待合併後重新載入
 }
 
 // NewModel 建立初始 Model。
-func NewModel() Model {
-	return Model{}
+func NewModel(deps Deps) Model {
+	return Model{
+		deps:           deps,
+		split:          loadSplit(deps.Store),
+		previews:       make(map[string]preview),
+		previewLoading: make(map[string]bool),
+	}
 }
 
 // Init 實作 tea.Model 介面。
 func (m Model) Init() tea.Cmd {
-	return tea.SetWindowTitle("tmux session menu")
+	title := tea.SetWindowTitle("tmux session menu")
+	if m.deps.TmuxMgr == nil {
+		return title
+	}
+	cmds := []tea.Cmd{title, loadSessionsCmd(m.deps), tickCmd(m.pollInterval())}
+	if m.deps.Events != nil {
+		cmds = append(cmds, waitEventCmd(m.deps.Events))
+	}
+	if m.deps.Hooks != nil {
+		cmds = append(cmds, waitHookCmd(m.deps.Hooks))
+	}
+	return tea.Batch(cmds...)
 }
 
 // Update 處理訊息並更新模型狀態。
@@ -35,7 +82,71 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		return m, nil
+	case tickMsg:
+		return m, tea.Batch(loadSessionsCmd(m.deps), tickCmd(m.pollInterval()))
+	case eventMsg:
+		cmds := []tea.Cmd{waitEventCmd(m.deps.Events)}
+		if tmux.Notification(msg).Structural() && !m.refreshPending {
+			m.refreshPending = true
+			cmds = append(cmds, refreshCmd())
+		}
+		return m, tea.Batch(cmds...)
+	case HookMsg:
+		return m.applyHook(tmux.HookUpdate(msg))
+	case refreshMsg:
+		m.refreshPending = false
+		return m, loadSessionsCmd(m.deps)
+	case SessionsMsg:
+		m.err = msg.Err
+		if msg.Err == nil {
+			m.groups = msg.Groups
+			m.sessions = msg.Sessions
+			m.rebuildItems()
+			m.prunePreviews()
+			return m, m.previewCmd(true)
+		}
+		return m, nil
+	case previewMsg:
+		return m.applyPreview(msg), nil
+	case sentMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.notice = fmt.Sprintf("%s → %s", msg.action.action, msg.action.session)
+		if m.refreshPending || m.deps.TmuxMgr == nil {
+			return m, nil
+		}
+		m.refreshPending = true
+		return m, refreshCmd()
+	case createdMsg:
+		if msg.err != nil {
+			if m.form != nil {
+				form := *m.form
+				form.err = msg.err.Error()
+				m.form = &form
+			} else {
+				m.err = msg.err
+			}
+			return m, nil
+		}
+		m.form = nil
+		m.selected = msg.name
+		m.quitting = true
+		return m, tea.Quit
 	case tea.KeyMsg:
+		if m.form != nil {
+			return m.updateForm(msg)
+		}
+		if m.reply != nil {
+			return m.updateReply(msg)
+		}
+		if m.search != nil {
+			return m.updateSearch(msg)
+		}
+		if m.pending != nil {
+			return m.updateConfirm(msg)
+		}
 		switch msg.String() {
 		case "q", "esc", "ctrl+c":
 			m.quitting = true
@@ -44,11 +155,37 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.cursor < len(m.items)-1 {
 				m.cursor++
 			}
-			return m, nil
+			return m, m.previewCmd(false)
 		case "k", "up":
 			if m.cursor > 0 {
 				m.cursor--
 			}
+			return m, m.previewCmd(false)
+		case "enter":
+			return m.activate()
+		case "tab":
+			if item, ok := m.current(); ok && item.Type == ItemGroup {
+				return m.toggleGroup(item.Group.ID)
+			}
+			return m, nil
+		case "/":
+			m.search = &lineInput{prompt: "/"}
+			return m, nil
+		case "n":
+			m.form = m.newForm()
+			return m, nil
+		case "<":
+			return m.adjustSplit(-splitStep)
+		case ">":
+			return m.adjustSplit(splitStep)
+		case "a":
+			return m.quick(actionApprove, "")
+		case "x":
+			return m.quick(actionDeny, "")
+		case "r":
+			if item, ok := m.current(); ok && item.Type == ItemSession {
+				m.reply = &lineInput{prompt: "回覆 " + item.Session.Name + ":"}
+			}
 			return m, nil
 		}
 	}
@@ -60,7 +197,25 @@ func (m Model) View() string {
 	if m.quitting {
 		return ""
 	}
+	if m.form != nil {
+		return headerStyle.Render("tmux session menu") + "\n\n" + m.form.View()
+	}
+	if m.sideBySide() {
+		return m.viewSideBySide()
+	}
+
+	list := m.renderList()
+	// 預覽區填滿剩餘高度（扣除預覽區的上框線）。最後一列不加換行，
+	// 避免總列數超過視窗高度而讓畫面頂端被捲掉。
+	height := previewDefaultHeight
+	if m.height > 0 {
+		height = m.height - strings.Count(list, "\n") - 1
+	}
+	return list + m.renderPreview(m.viewWidth(), max(height, previewMinHeight), previewBorderStyle)
+}
 
+// renderList 渲染標題、session 列表、說明列與操作結果，每列皆以換行結尾。
+func (m Model) renderList() string {
 	var b strings.Builder
 
 	// Header
@@ -68,6 +223,10 @@ func (m Model) View() string {
 	b.WriteString(dimStyle.Render("  (↑↓/jk 選擇, Enter 確認, q 離開)"))
 	b.WriteString("\n")
 
+	if m.err != nil {
+		b.WriteString("\n  " + statusErrorStyle.Render(m.err.Error()) + "\n")
+	}
+
 	// Items list
 	if len(m.items) > 0 {
 		b.WriteString("\n")
@@ -86,53 +245,258 @@ func (m Model) View() string {
 				b.WriteString(fmt.Sprintf("%s%s %s\n",
 					cursor,
 					selectedStyle.Render(collapse),
-					selectedStyle.Render(item.Group.Name)))
+					highlight(item.Group.Name, item.Highlight.Name, selectedStyle)))
 
 			case ItemSession:
 				icon := item.Session.StatusIcon()
 				styledIcon := statusStyleFor(item.Session.Status).Render(icon)
 
+				wait := ""
+				if label := waitLabel(item.Session.Wait); label != "" {
+					wait = " " + label
+				}
+
 				relTime := ""
 				if !item.Session.Activity.IsZero() {
 					relTime = "  " + dimStyle.Render(item.Session.RelativeTime())
 				}
 
+				badge := ""
+				if item.Session.Agent != "" {
+					badge = "  " + agentBadgeStyle.Render(agentBadge(item.Session.Agent))
+				}
+
 				aiModel := ""
 				if item.Session.AIModel != "" {
-					aiModel = "  " + dimStyle.Render(item.Session.AIModel)
+					aiModel = "  " + highlight(item.Session.AIModel, item.Highlight.Model, dimStyle)
 				}
 
-				name := item.Session.Name
+				nameStyle := lipgloss.NewStyle()
 				if i == m.cursor {
-					name = selectedStyle.Render(name)
+					nameStyle = selectedStyle
+				}
+				name := highlight(item.Session.Name, item.Highlight.Name, nameStyle)
+				if item.Session.CustomName != "" {
+					name += " " + highlight(item.Session.CustomName, item.Highlight.CustomName, dimStyle)
 				}
 
-				b.WriteString(fmt.Sprintf("%s   %s  %s%s%s\n",
-					cursor, name, styledIcon, relTime, aiModel))
+				b.WriteString(fmt.Sprintf("%s   %s  %s%s%s%s%s\n",
+					cursor, name, styledIcon, wait, relTime, badge, aiModel))
 			}
 		}
 	}
 
 	// Help bar
-	b.WriteString(fmt.Sprintf("\n  %s  %s  %s\n",
-		dimStyle.Render("[n] 新建"),
-		dimStyle.Render("[g] 新群組"),
-		dimStyle.Render("[q] 離開")))
-
-	// Preview section
-	if len(m.items) > 0 && m.cursor >= 0 && m.cursor < len(m.items) {
-		selected := m.items[m.cursor]
-		if selected.Type == ItemSession && selected.Session.AISummary != "" {
-			b.WriteString("\n")
-			b.WriteString(previewBorderStyle.Render(
-				fmt.Sprintf("Preview: %s", selected.Session.AISummary)))
-			b.WriteString("\n")
+	switch {
+	case m.reply != nil:
+		b.WriteString("\n  " + m.reply.View() + "\n")
+	case m.search != nil:
+		b.WriteString("\n  " + m.search.View() + "  " + dimStyle.Render(m.matchCount()) + "\n")
+	case m.pending != nil:
+		b.WriteString("\n  " + statusWaitingStyle.Render(m.pending.confirmText()) + "\n")
+	default:
+		help := []string{"[/] 搜尋", "[a/x] 同意/拒絕", "[r] 回覆", "[n] 新建", "[g] 新群組", "[q] 離開"}
+		if m.sideBySide() {
+			help = append(help[:len(help)-1], "[</>] 調整分割", help[len(help)-1])
+		}
+		for i, h := range help {
+			help[i] = dimStyle.Render(h)
 		}
+		b.WriteString("\n  " + strings.Join(help, "  ") + "\n")
+	}
+	if m.notice != "" {
+		b.WriteString("  " + dimStyle.Render(m.notice) + "\n")
 	}
 
 	return b.String()
 }
 
+// applyHook 將 hook 狀態直接套用到對應 session，不必等待下一次輪詢。
+// 狀態檔案被刪除或已過期時改為重新載入，交由三層偵測重新判斷。
+func (m Model) applyHook(u tmux.HookUpdate) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	if m.deps.Hooks != nil {
+		cmds = append(cmds, waitHookCmd(m.deps.Hooks))
+	}
+
+	if u.Status == nil || !u.Status.IsValid() {
+		if !m.refreshPending && m.deps.TmuxMgr != nil {
+			m.refreshPending = true
+			cmds = append(cmds, refreshCmd())
+		}
+		return m, tea.Batch(cmds...)
+	}
+
+	sessions := make([]tmux.Session, len(m.sessions))
+	copy(sessions, m.sessions)
+	for i := range sessions {
+		if sessions[i].ID == u.SessionID {
+			sessions[i] = sessions[i].WithHookStatus(*u.Status)
+			m.trackHook(sessions[i], *u.Status)
+		}
+	}
+	m.sessions = sessions
+	m.rebuildItems()
+	return m, tea.Batch(cmds...)
+}
+
+// trackHook 將 hook 狀態同步到 Tracker，避免下一次輪詢時被防抖動規則延後。
+func (m Model) trackHook(s tmux.Session, h tmux.HookStatus) {
+	if m.deps.Tracker == nil {
+		return
+	}
+	for _, w := range s.Windows {
+		for _, p := range w.Panes {
+			if h.AppliesTo(p.ID) {
+				key := tmux.TrackerKey{Session: s.ID, Pane: p.ID}
+				m.deps.Tracker.Set(key, h.Status, tmux.SourceHook, time.Unix(h.Timestamp, 0))
+			}
+		}
+	}
+}
+
+// current 回傳游標所在的項目。
+func (m Model) current() (ListItem, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.items) {
+		return ListItem{}, false
+	}
+	return m.items[m.cursor], true
+}
+
+// activate 處理 Enter：session 列記錄選取並離開，群組列切換折疊。
+func (m Model) activate() (tea.Model, tea.Cmd) {
+	item, ok := m.current()
+	if !ok {
+		return m, nil
+	}
+	if item.Type == ItemGroup {
+		return m.toggleGroup(item.Group.ID)
+	}
+	m.selected = item.Session.Name
+	m.quitting = true
+	return m, tea.Quit
+}
+
+// quick 對游標所在的 session 執行操作。session 的等待原因不符合操作時先要求確認。
+func (m Model) quick(a quickAction, reply string) (tea.Model, tea.Cmd) {
+	item, ok := m.current()
+	if !ok || item.Type != ItemSession {
+		return m, nil
+	}
+	p := newPendingAction(a, item.Session, reply)
+	if !p.expected() {
+		m.pending = &p
+		return m, nil
+	}
+	return m.send(p)
+}
+
+// send 送出操作。
+func (m Model) send(p pendingAction) (tea.Model, tea.Cmd) {
+	if m.deps.TmuxMgr == nil {
+		return m, nil
+	}
+	return m, sendActionCmd(m.deps.TmuxMgr, p)
+}
+
+// updateConfirm 處理操作確認：y 送出，其他按鍵取消。
+func (m Model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := *m.pending
+	m.pending = nil
+	if msg.String() != "y" {
+		m.notice = p.action.String() + " canceled"
+		return m, nil
+	}
+	return m.send(p)
+}
+
+// updateReply 處理回覆輸入，Enter 送出非空白的回覆。
+func (m Model) updateReply(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	in := *m.reply
+	switch in.update(msg) {
+	case inputSubmitted:
+		m.reply = nil
+		if strings.TrimSpace(in.String()) == "" {
+			return m, nil
+		}
+		return m.quick(actionReply, in.String())
+	case inputCanceled:
+		m.reply = nil
+	default:
+		m.reply = &in
+	}
+	return m, nil
+}
+
+// updateForm 處理新建 session 表單，通過驗證後在背景建立 session。
+func (m Model) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	form := *m.form
+	req, canceled := form.update(msg)
+	if canceled {
+		m.form = nil
+		return m, nil
+	}
+	m.form = &form
+	if req == nil || m.deps.TmuxMgr == nil {
+		return m, nil
+	}
+	return m, createSessionCmd(m.deps, *req)
+}
+
+// toggleGroup 切換群組的折疊狀態並寫回 store。
+func (m Model) toggleGroup(id int64) (tea.Model, tea.Cmd) {
+	groups := make([]store.Group, len(m.groups))
+	copy(groups, m.groups)
+	for i := range groups {
+		if groups[i].ID != id {
+			continue
+		}
+		groups[i].Collapsed = !groups[i].Collapsed
+		if m.deps.Store != nil {
+			if err := m.deps.Store.SetGroupCollapsed(id, groups[i].Collapsed); err != nil {
+				m.err = err
+				return m, nil
+			}
+		}
+	}
+	m.groups = groups
+	m.rebuildItems()
+	return m, nil
+}
+
+// agentBadges 是 agent 名稱對應的短標籤。
+var agentBadges = map[string]string{
+	"claude-code": "[claude]",
+	"codex":       "[codex]",
+	"gemini":      "[gemini]",
+	"aider":       "[aider]",
+}
+
+// agentBadge 回傳 agent 的短標籤，未知的 agent 直接使用名稱。
+func agentBadge(agent string) string {
+	if badge, ok := agentBadges[agent]; ok {
+		return badge
+	}
+	return "[" + agent + "]"
+}
+
+// waitLabelWidth 是等待原因標籤的最大顯示寬度。
+const waitLabelWidth = 40
+
+// waitLabel 回傳等待原因標籤。卡在等待決定（權限、計畫、問題）的 session 以醒目顏色顯示，
+// 單純回到提示字元的則淡化顯示。
+func waitLabel(w tmux.WaitInfo) string {
+	summary := runewidth.Truncate(w.Summary(), waitLabelWidth, "…")
+	switch {
+	case summary == "":
+		return ""
+	case w.Reason.Blocking():
+		return statusWaitingStyle.Render(summary)
+	default:
+		return dimStyle.Render(summary)
+	}
+}
+
 // statusStyleFor 回傳對應狀態的 lipgloss 樣式。
 func statusStyleFor(status tmux.SessionStatus) lipgloss.Style {
 	switch status {
@@ -147,14 +511,31 @@ func statusStyleFor(status tmux.SessionStatus) lipgloss.Style {
 	}
 }
 
-// SetItems 設定列表項目（主要用於測試）。
+// SetItems 設定列表項目，若原本選取的項目仍存在則游標跟隨該項目。
 func (m *Model) SetItems(items []ListItem) {
+	var selected string
+	if m.cursor >= 0 && m.cursor < len(m.items) {
+		selected = m.items[m.cursor].key()
+	}
 	m.items = items
+	if selected != "" {
+		for i, item := range items {
+			if item.key() == selected {
+				m.cursor = i
+				return
+			}
+		}
+	}
 	if m.cursor >= len(items) && len(items) > 0 {
 		m.cursor = len(items) - 1
 	}
 }
 
+// Selected 回傳使用者按 Enter 選取的 session 名稱（未選取時為空字串）。
+func (m Model) Selected() string {
+	return m.selected
+}
+
 // Cursor 回傳目前游標位置。
 func (m Model) Cursor() int {
 	return m.cursor
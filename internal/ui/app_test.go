@@ -6,32 +6,33 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/wake/tmux-session-menu/internal/store"
 	"github.com/wake/tmux-session-menu/internal/tmux"
 	"github.com/wake/tmux-session-menu/internal/ui"
 )
 
 func TestModel_Init(t *testing.T) {
-	m := ui.NewModel()
+	m := ui.NewModel(ui.Deps{})
 	cmd := m.Init()
 	assert.NotNil(t, cmd)
 }
 
 func TestModel_View_ShowsHeader(t *testing.T) {
-	m := ui.NewModel()
+	m := ui.NewModel(ui.Deps{})
 	view := m.View()
 	assert.Contains(t, view, "tmux session menu")
 }
 
 func TestModel_Quit(t *testing.T) {
-	m := ui.NewModel()
+	m := ui.NewModel(ui.Deps{})
 	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
 	_ = updated
 	assert.NotNil(t, cmd)
 }
 
 func TestModel_Navigation(t *testing.T) {
-	m := ui.NewModel()
+	m := ui.NewModel(ui.Deps{})
 	m.SetItems([]ui.ListItem{
 		{Type: ui.ItemSession},
 		{Type: ui.ItemSession},
@@ -58,7 +59,7 @@ func TestModel_Navigation(t *testing.T) {
 }
 
 func TestModel_View_RendersSessions(t *testing.T) {
-	m := ui.NewModel()
+	m := ui.NewModel(ui.Deps{})
 	m.SetItems([]ui.ListItem{
 		{Type: ui.ItemGroup, Group: store.Group{Name: "dev"}},
 		{Type: ui.ItemSession, Session: tmux.Session{
@@ -84,8 +85,20 @@ func TestModel_View_RendersSessions(t *testing.T) {
 	assert.Contains(t, view, "claude-sonnet-4-6")
 }
 
+func TestModel_View_AgentBadge(t *testing.T) {
+	m := ui.NewModel(ui.Deps{})
+	m.SetItems([]ui.ListItem{
+		{Type: ui.ItemSession, Session: tmux.Session{Name: "a", Agent: "codex"}},
+		{Type: ui.ItemSession, Session: tmux.Session{Name: "b", Agent: "my-agent"}},
+	})
+
+	view := m.View()
+	assert.Contains(t, view, "[codex]")
+	assert.Contains(t, view, "[my-agent]")
+}
+
 func TestModel_View_Preview(t *testing.T) {
-	m := ui.NewModel()
+	m := ui.NewModel(ui.Deps{})
 	m.SetItems([]ui.ListItem{
 		{Type: ui.ItemSession, Session: tmux.Session{
 			Name:      "my-project",
@@ -97,8 +110,142 @@ func TestModel_View_Preview(t *testing.T) {
 	assert.Contains(t, view, "正在重構 auth 模組")
 }
 
+func TestModel_Enter_SelectsSession(t *testing.T) {
+	m := ui.NewModel(ui.Deps{})
+	m.SetItems([]ui.ListItem{
+		{Type: ui.ItemSession, Session: tmux.Session{Name: "alpha"}},
+		{Type: ui.ItemSession, Session: tmux.Session{Name: "beta"}},
+	})
+
+	m, _ = applyKey(m, "j")
+	m, cmd := applySpecialKey(m, tea.KeyEnter)
+
+	assert.Equal(t, "beta", m.Selected())
+	assert.NotNil(t, cmd)
+	assert.Empty(t, m.View())
+}
+
+func TestModel_SetItems_FollowsRenamedSession(t *testing.T) {
+	m := ui.NewModel(ui.Deps{})
+	m.SetItems([]ui.ListItem{
+		{Type: ui.ItemSession, Session: tmux.Session{ID: "$1", Name: "alpha"}},
+		{Type: ui.ItemSession, Session: tmux.Session{ID: "$2", Name: "beta"}},
+		{Type: ui.ItemSession, Session: tmux.Session{ID: "$3", Name: "gamma"}},
+	})
+	m, _ = applyKey(m, "j")
+	require.Equal(t, 1, m.Cursor())
+
+	// beta 改名為 zeta 後排到最後，游標跟著它
+	m.SetItems([]ui.ListItem{
+		{Type: ui.ItemSession, Session: tmux.Session{ID: "$1", Name: "alpha"}},
+		{Type: ui.ItemSession, Session: tmux.Session{ID: "$3", Name: "gamma"}},
+		{Type: ui.ItemSession, Session: tmux.Session{ID: "$2", Name: "zeta"}},
+	})
+	assert.Equal(t, 2, m.Cursor())
+}
+
+func TestModel_Enter_TogglesGroup(t *testing.T) {
+	m := ui.NewModel(ui.Deps{})
+	updated, _ := m.Update(ui.SessionsMsg{
+		Groups:   []store.Group{{ID: 1, Name: "dev"}},
+		Sessions: []tmux.Session{{Name: "a", GroupName: "dev"}, {Name: "b", GroupName: "dev"}},
+	})
+	m = updated.(ui.Model)
+	assert.Contains(t, m.View(), "b")
+
+	m, cmd := applySpecialKey(m, tea.KeyEnter)
+	assert.Nil(t, cmd)
+	assert.Empty(t, m.Selected())
+	assert.Contains(t, m.View(), "▶")
+	assert.NotContains(t, m.View(), "   a  ")
+
+	m, _ = applySpecialKey(m, tea.KeyTab)
+	assert.Contains(t, m.View(), "▼")
+	assert.Equal(t, 0, m.Cursor())
+}
+
+func applySpecialKey(m ui.Model, key tea.KeyType) (ui.Model, tea.Cmd) {
+	updated, cmd := m.Update(tea.KeyMsg{Type: key})
+	return updated.(ui.Model), cmd
+}
+
 func applyKey(m ui.Model, key string) (ui.Model, tea.Cmd) {
 	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
 	updated, cmd := m.Update(msg)
 	return updated.(ui.Model), cmd
 }
+
+// waitingSession 回傳 pane %2 正在等待指定原因的 session。
+func waitingSession(reason tmux.WaitReason) tmux.Session {
+	wait := tmux.WaitInfo{Reason: reason}
+	return tmux.Session{Name: "work", Status: tmux.StatusWaiting, Wait: wait, Windows: []tmux.Window{{
+		Panes: []tmux.Pane{
+			{ID: "%1", Active: true, Status: tmux.StatusIdle},
+			{ID: "%2", Status: tmux.StatusWaiting, Wait: wait},
+		},
+	}}}
+}
+
+func TestModel_QuickApprove(t *testing.T) {
+	exec := &fakeExecutor{}
+	m := ui.NewModel(ui.Deps{TmuxMgr: tmux.NewManager(exec)})
+	m.SetItems([]ui.ListItem{{Type: ui.ItemSession, Session: waitingSession(tmux.ReasonPermission)}})
+
+	m, cmd := applyKey(m, "a")
+	require.NotNil(t, cmd)
+	updated, _ := m.Update(cmd())
+	m = updated.(ui.Model)
+
+	assert.Equal(t, []string{"send-keys -t %2 Enter"}, exec.calls)
+	assert.Contains(t, m.View(), "approve → work")
+}
+
+func TestModel_QuickDeny_Question(t *testing.T) {
+	exec := &fakeExecutor{}
+	m := ui.NewModel(ui.Deps{TmuxMgr: tmux.NewManager(exec)})
+	m.SetItems([]ui.ListItem{{Type: ui.ItemSession, Session: waitingSession(tmux.ReasonQuestion)}})
+
+	_, cmd := applyKey(m, "x")
+	require.NotNil(t, cmd)
+	cmd()
+	assert.Equal(t, []string{"send-keys -t %2 -l -- n", "send-keys -t %2 Enter"}, exec.calls)
+}
+
+func TestModel_QuickApprove_ConfirmsMismatch(t *testing.T) {
+	exec := &fakeExecutor{}
+	m := ui.NewModel(ui.Deps{TmuxMgr: tmux.NewManager(exec)})
+	m.SetItems([]ui.ListItem{{Type: ui.ItemSession, Session: waitingSession(tmux.ReasonPrompt)}})
+
+	m, cmd := applyKey(m, "a")
+	assert.Nil(t, cmd)
+	assert.Contains(t, m.View(), "仍要送出 approve")
+
+	// 確認以外的按鍵取消操作
+	m, cmd = applyKey(m, "n")
+	assert.Nil(t, cmd)
+	assert.NotContains(t, m.View(), "仍要送出")
+	assert.Empty(t, exec.calls)
+
+	m, _ = applyKey(m, "a")
+	_, cmd = applyKey(m, "y")
+	require.NotNil(t, cmd)
+	cmd()
+	assert.Equal(t, []string{"send-keys -t %2 Enter"}, exec.calls)
+}
+
+func TestModel_QuickReply(t *testing.T) {
+	exec := &fakeExecutor{}
+	m := ui.NewModel(ui.Deps{TmuxMgr: tmux.NewManager(exec)})
+	m.SetItems([]ui.ListItem{{Type: ui.ItemSession, Session: waitingSession(tmux.ReasonPrompt)}})
+
+	m, _ = applyKey(m, "r")
+	m, _ = applyKey(m, "run")
+	m, _ = applySpecialKey(m, tea.KeySpace)
+	m, _ = applyKey(m, "tests")
+	assert.Contains(t, m.View(), "run tests")
+
+	_, cmd := applySpecialKey(m, tea.KeyEnter)
+	require.NotNil(t, cmd)
+	cmd()
+	assert.Equal(t, []string{"send-keys -t %2 -l -- run tests", "send-keys -t %2 Enter"}, exec.calls)
+}
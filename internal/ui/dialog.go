@@ -0,0 +1,371 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/wake/tmux-session-menu/internal/config"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+)
+
+// 新建 session 表單的欄位。
+const (
+	formName = iota
+	formDir
+	formTemplate
+	formGroup
+	formCommand
+	formFieldCount
+)
+
+// formLabels 是各欄位的標籤。
+var formLabels = [formFieldCount]string{"名稱", "目錄", "範本", "群組", "指令"}
+
+// maxCandidates 是表單中顯示的補全候選數量上限。
+const maxCandidates = 6
+
+// newSessionForm 是建立 session 的表單。
+type newSessionForm struct {
+	inputs    [formFieldCount]lineInput
+	focus     int
+	err       string
+	existing  []string // 目前已存在的 session 名稱
+	dirs      []string // 目錄候選：frecency 由高到低，再加上目前 session 的路徑
+	groups    []string
+	templates []string // 設定檔中的範本名稱
+
+	completions []string // 連續按 Tab 時循環的候選
+	completion  int
+}
+
+// newSessionRequest 是表單驗證後要建立的 session。
+type newSessionRequest struct {
+	name     string
+	dir      string
+	template string // 範本名稱，空字串表示只建立單一 pane
+	group    string
+	command  string
+}
+
+// createdMsg 是建立 session 的結果。
+type createdMsg struct {
+	name string
+	err  error
+}
+
+// newForm 建立表單，目錄預設為目前工作目錄，群組預設為游標所在的群組。
+func (m Model) newForm() *newSessionForm {
+	f := &newSessionForm{}
+	for _, s := range m.sessions {
+		f.existing = append(f.existing, s.Name)
+	}
+	if m.deps.Store != nil {
+		if dirs, err := m.deps.Store.FrecentDirectories(time.Now()); err == nil {
+			for _, d := range dirs {
+				f.dirs = append(f.dirs, d.Path)
+			}
+		}
+	}
+	for _, s := range m.sessions {
+		if s.Path != "" && !slices.Contains(f.dirs, s.Path) {
+			f.dirs = append(f.dirs, s.Path)
+		}
+	}
+	for _, g := range m.groups {
+		f.groups = append(f.groups, g.Name)
+	}
+	for _, t := range m.deps.Cfg.Templates {
+		f.templates = append(f.templates, t.Name)
+	}
+
+	if wd, err := os.Getwd(); err == nil {
+		f.inputs[formDir].value = []rune(shortenHome(wd))
+	}
+	if item, ok := m.current(); ok {
+		switch item.Type {
+		case ItemGroup:
+			f.inputs[formGroup].value = []rune(item.Group.Name)
+		case ItemSession:
+			f.inputs[formGroup].value = []rune(item.Session.GroupName)
+		}
+	}
+	return f
+}
+
+// shortenHome 將家目錄開頭的路徑改以 ~ 表示。
+func shortenHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return "~/" + rest
+	}
+	return path
+}
+
+// update 處理表單按鍵。回傳非 nil 的 request 表示已通過驗證、應建立 session；
+// canceled 為 true 表示使用者關閉表單。
+func (f *newSessionForm) update(msg tea.KeyMsg) (req *newSessionRequest, canceled bool) {
+	if msg.Type != tea.KeyTab {
+		f.completions = nil
+	}
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		return nil, true
+	case "tab":
+		if f.completable() {
+			f.complete()
+		} else {
+			f.focus = (f.focus + 1) % formFieldCount
+		}
+		return nil, false
+	case "shift+tab", "up":
+		f.focus = (f.focus + formFieldCount - 1) % formFieldCount
+		return nil, false
+	case "down":
+		f.focus = (f.focus + 1) % formFieldCount
+		return nil, false
+	case "enter":
+		if f.focus < formCommand {
+			f.focus++
+			return nil, false
+		}
+		r, err := f.request()
+		if err != nil {
+			f.err = err.Error()
+			return nil, false
+		}
+		return &r, false
+	}
+	f.inputs[f.focus].update(msg)
+	f.err = ""
+	if f.focus == formName {
+		if err := f.validateName(); err != nil {
+			f.err = err.Error()
+		}
+	}
+	return nil, false
+}
+
+// completable 回傳目前欄位是否可以 Tab 補全。
+func (f *newSessionForm) completable() bool {
+	return f.focus == formDir || f.focus == formTemplate || f.focus == formGroup
+}
+
+// complete 以候選補全目前欄位；連續按 Tab 時依序循環其他候選。
+func (f *newSessionForm) complete() {
+	if f.completions == nil {
+		f.completions = f.candidates()
+		f.completion = 0
+	}
+	if len(f.completions) == 0 {
+		return
+	}
+	f.inputs[f.focus].value = []rune(f.completions[f.completion%len(f.completions)])
+	f.completion++
+}
+
+// candidates 回傳目前欄位的補全候選。
+func (f *newSessionForm) candidates() []string {
+	value := f.inputs[f.focus].String()
+	switch f.focus {
+	case formDir:
+		return dirCandidates(value, f.dirs)
+	case formTemplate:
+		return matchPrefix(value, f.templates)
+	case formGroup:
+		return matchPrefix(value, f.groups)
+	}
+	return nil
+}
+
+// matchPrefix 回傳以 value 開頭（不分大小寫）的候選。
+func matchPrefix(value string, candidates []string) []string {
+	var out []string
+	for _, c := range candidates {
+		if strings.HasPrefix(strings.ToLower(c), strings.ToLower(value)) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// dirCandidates 回傳目錄候選：先列出包含 value 的常用目錄（依 frecency），
+// 再列出檔案系統中以 value 為前綴的子目錄。
+func dirCandidates(value string, recent []string) []string {
+	var out []string
+	needle := strings.ToLower(value)
+	for _, d := range recent {
+		short := shortenHome(d)
+		if strings.Contains(strings.ToLower(short), needle) || strings.Contains(strings.ToLower(d), needle) {
+			out = append(out, short)
+		}
+	}
+	for _, d := range subdirCompletions(value) {
+		if !slices.Contains(out, d) {
+			out = append(out, d)
+		}
+	}
+	if len(out) > maxCandidates {
+		out = out[:maxCandidates]
+	}
+	return out
+}
+
+// subdirCompletions 以檔案系統補全路徑的最後一段，只列出目錄；
+// 除非最後一段以 "." 開頭，否則略過隱藏目錄。
+func subdirCompletions(value string) []string {
+	if value == "" {
+		return nil
+	}
+	parent, prefix := value[:strings.LastIndex(value, "/")+1], value[strings.LastIndex(value, "/")+1:]
+	if parent == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(config.ExpandPath(parent))
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || !strings.HasPrefix(name, prefix) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		out = append(out, parent+name)
+	}
+	return out
+}
+
+// validateName 檢查名稱是否合法且尚未使用。
+func (f *newSessionForm) validateName() error {
+	name := strings.TrimSpace(f.inputs[formName].String())
+	if err := tmux.ValidateSessionName(name); err != nil {
+		return err
+	}
+	if slices.Contains(f.existing, name) {
+		return fmt.Errorf("session %q already exists", name)
+	}
+	return nil
+}
+
+// request 驗證所有欄位並回傳要建立的 session。
+func (f *newSessionForm) request() (newSessionRequest, error) {
+	if err := f.validateName(); err != nil {
+		f.focus = formName
+		return newSessionRequest{}, err
+	}
+	dir := strings.TrimSpace(f.inputs[formDir].String())
+	if dir == "" {
+		f.focus = formDir
+		return newSessionRequest{}, errors.New("directory must not be empty")
+	}
+	dir = filepath.Clean(config.ExpandPath(dir))
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		f.focus = formDir
+		return newSessionRequest{}, fmt.Errorf("%s is not a directory", dir)
+	}
+	template := strings.TrimSpace(f.inputs[formTemplate].String())
+	if template != "" && !slices.Contains(f.templates, template) {
+		f.focus = formTemplate
+		return newSessionRequest{}, fmt.Errorf("unknown template %q", template)
+	}
+	return newSessionRequest{
+		name:     strings.TrimSpace(f.inputs[formName].String()),
+		dir:      dir,
+		template: template,
+		group:    strings.TrimSpace(f.inputs[formGroup].String()),
+		command:  strings.TrimSpace(f.inputs[formCommand].String()),
+	}, nil
+}
+
+// View 渲染表單。
+func (f *newSessionForm) View() string {
+	var b strings.Builder
+	b.WriteString(selectedStyle.Render("新建 session") + "\n\n")
+	placeholders := [formFieldCount]string{
+		formTemplate: "（選填，Tab 選擇）",
+		formGroup:    "（選填）",
+		formCommand:  "（選填，例如 claude）",
+	}
+	if len(f.templates) == 0 {
+		placeholders[formTemplate] = "（設定檔中沒有 [[template]]）"
+	}
+	for i := range f.inputs {
+		label := dimStyle.Render(formLabels[i])
+		value := f.inputs[i].String()
+		switch {
+		case i == f.focus:
+			label = selectedStyle.Render("► " + formLabels[i])
+			value += selectedStyle.Render("█")
+		case value == "":
+			value = dimStyle.Render(placeholders[i])
+		}
+		if i != f.focus {
+			label = "  " + label
+		}
+		b.WriteString(fmt.Sprintf("  %s  %s\n", label, value))
+	}
+	if f.err != "" {
+		b.WriteString("\n  " + statusErrorStyle.Render(f.err) + "\n")
+	}
+	if f.completable() {
+		candidates := f.completions
+		if candidates == nil {
+			candidates = f.candidates()
+		}
+		if len(candidates) > 0 {
+			b.WriteString("\n  " + dimStyle.Render(strings.Join(candidates, "  ")) + "\n")
+		}
+	}
+	b.WriteString("\n  " + dimStyle.Render("[Tab] 補全  [↑↓] 切換欄位  [Enter] 下一步/建立  [Esc] 取消") + "\n")
+	return b.String()
+}
+
+// createSessionCmd 在背景建立 session（有範本時依範本建立 window 與 pane）：
+// 在第一個 pane 執行啟動指令、記錄目錄使用紀錄並指定群組。群組不存在時自動建立。
+func createSessionCmd(deps Deps, req newSessionRequest) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		if req.template == "" {
+			err = deps.TmuxMgr.NewSession(req.name, req.dir)
+		} else if tpl, ok := deps.Cfg.FindTemplate(req.template); ok {
+			err = deps.TmuxMgr.NewSessionFromTemplate(req.name, req.dir, tpl)
+		} else {
+			err = fmt.Errorf("unknown template %q", req.template)
+		}
+		if err != nil {
+			return createdMsg{name: req.name, err: fmt.Errorf("create session: %w", err)}
+		}
+		if req.command != "" {
+			target := tmux.SessionPane(req.name)
+			if err := deps.TmuxMgr.SendLiteral(target, req.command); err != nil {
+				return createdMsg{name: req.name, err: fmt.Errorf("run command: %w", err)}
+			}
+			if err := deps.TmuxMgr.SendKeys(target, "Enter"); err != nil {
+				return createdMsg{name: req.name, err: fmt.Errorf("run command: %w", err)}
+			}
+		}
+		if deps.Store == nil {
+			return createdMsg{name: req.name}
+		}
+		// 常用目錄只影響補全排序，寫入失敗不影響建立結果
+		_ = deps.Store.TouchDirectory(req.dir, time.Now())
+		if req.group != "" {
+			if err := deps.Store.AssignSessionGroup(req.name, req.group, 0); err != nil {
+				return createdMsg{name: req.name, err: fmt.Errorf("assign group: %w", err)}
+			}
+		}
+		return createdMsg{name: req.name}
+	}
+}
@@ -0,0 +1,162 @@
+package ui_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wake/tmux-session-menu/internal/config"
+	"github.com/wake/tmux-session-menu/internal/store"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+	"github.com/wake/tmux-session-menu/internal/ui"
+)
+
+// typeDir 清空目錄欄位後輸入 dir。
+func typeDir(m ui.Model, dir string) ui.Model {
+	m, _ = applySpecialKey(m, tea.KeyCtrlU)
+	m, _ = applyKey(m, dir)
+	return m
+}
+
+func TestModel_NewSession(t *testing.T) {
+	exec := &fakeExecutor{}
+	st, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { st.Close() })
+	dir := t.TempDir()
+
+	m := ui.NewModel(ui.Deps{TmuxMgr: tmux.NewManager(exec), Store: st})
+	m, _ = applyKey(m, "n")
+	assert.Contains(t, m.View(), "新建 session")
+
+	m, _ = applyKey(m, "api")
+	m, _ = applySpecialKey(m, tea.KeyEnter)
+	m = typeDir(m, dir)
+	m, _ = applySpecialKey(m, tea.KeyEnter)
+	m, _ = applySpecialKey(m, tea.KeyEnter)
+	m, _ = applyKey(m, "backend")
+	m, _ = applySpecialKey(m, tea.KeyEnter)
+	m, _ = applyKey(m, "claude")
+	m, cmd := applySpecialKey(m, tea.KeyEnter)
+	require.NotNil(t, cmd)
+
+	updated, quit := m.Update(cmd())
+	m = updated.(ui.Model)
+	assert.NotNil(t, quit)
+	assert.Equal(t, "api", m.Selected())
+	assert.Equal(t, []string{
+		"new-session -d -s api -c " + dir,
+		"send-keys -t =api: -l -- claude",
+		"send-keys -t =api: Enter",
+	}, exec.calls)
+
+	groups, err := st.ListGroups()
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, "backend", groups[0].Name)
+	metas, err := st.ListSessionMetas(groups[0].ID)
+	require.NoError(t, err)
+	require.Len(t, metas, 1)
+	assert.Equal(t, "api", metas[0].SessionName)
+
+	dirs, err := st.FrecentDirectories(time.Now())
+	require.NoError(t, err)
+	require.Len(t, dirs, 1)
+	assert.Equal(t, dir, dirs[0].Path)
+}
+
+func TestModel_NewSession_Validation(t *testing.T) {
+	exec := &fakeExecutor{}
+	m := ui.NewModel(ui.Deps{TmuxMgr: tmux.NewManager(exec)})
+	updated, _ := m.Update(ui.SessionsMsg{Sessions: []tmux.Session{{Name: "work"}}})
+	m = updated.(ui.Model)
+
+	m, _ = applyKey(m, "n")
+	m, _ = applyKey(m, "v1.2")
+	assert.Contains(t, m.View(), "must not contain")
+
+	m, _ = applySpecialKey(m, tea.KeyCtrlU)
+	m, _ = applyKey(m, "work")
+	assert.Contains(t, m.View(), "already exists")
+
+	m, _ = applyKey(m, "2")
+	assert.NotContains(t, m.View(), "already exists")
+
+	// 不存在的目錄在送出時擋下
+	m, _ = applySpecialKey(m, tea.KeyEnter)
+	m = typeDir(m, filepath.Join(t.TempDir(), "missing"))
+	for range 3 {
+		m, _ = applySpecialKey(m, tea.KeyEnter)
+	}
+	m, cmd := applySpecialKey(m, tea.KeyEnter)
+	assert.Nil(t, cmd)
+	assert.Contains(t, m.View(), "is not a directory")
+	assert.Empty(t, exec.calls)
+
+	m, _ = applySpecialKey(m, tea.KeyEsc)
+	assert.NotContains(t, m.View(), "新建 session")
+}
+
+func TestModel_NewSession_Template(t *testing.T) {
+	exec := &fakeExecutor{outputs: map[string]string{}}
+	cfg, err := config.LoadFromString(`
+[[template]]
+name = "dev"
+[[template.window]]
+name = "code"
+[[template.window.pane]]
+command = "nvim"
+[[template.window.pane]]
+split = "horizontal"
+command = "claude"
+`)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	exec.outputs["new-session -d -s api -P -F #{pane_id} -n code -c "+dir] = "%7"
+	exec.outputs["split-window -d -t %7 -P -F #{pane_id} -h -c "+dir] = "%8"
+
+	m := ui.NewModel(ui.Deps{TmuxMgr: tmux.NewManager(exec), Cfg: cfg})
+	m, _ = applyKey(m, "n")
+	m, _ = applyKey(m, "api")
+	m, _ = applySpecialKey(m, tea.KeyEnter)
+	m = typeDir(m, dir)
+	m, _ = applySpecialKey(m, tea.KeyEnter)
+	m, _ = applySpecialKey(m, tea.KeyTab)
+	assert.Contains(t, m.View(), "dev█")
+
+	m, _ = applySpecialKey(m, tea.KeyEnter)
+	m, _ = applySpecialKey(m, tea.KeyEnter)
+	_, cmd := applySpecialKey(m, tea.KeyEnter)
+	require.NotNil(t, cmd)
+	cmd()
+	assert.Equal(t, []string{
+		"new-session -d -s api -P -F #{pane_id} -n code -c " + dir,
+		"split-window -d -t %7 -P -F #{pane_id} -h -c " + dir,
+		"send-keys -t %7 -l -- nvim",
+		"send-keys -t %7 Enter",
+		"send-keys -t %8 -l -- claude",
+		"send-keys -t %8 Enter",
+	}, exec.calls)
+}
+
+func TestModel_NewSession_CompletesDirectory(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, "project"), 0o755))
+	require.NoError(t, os.Mkdir(filepath.Join(root, "private"), 0o755))
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".cache"), 0o755))
+
+	m := ui.NewModel(ui.Deps{})
+	m, _ = applyKey(m, "n")
+	m, _ = applySpecialKey(m, tea.KeyDown)
+	m = typeDir(m, root+"/pr")
+
+	m, _ = applySpecialKey(m, tea.KeyTab)
+	assert.Contains(t, m.View(), root+"/private")
+	m, _ = applySpecialKey(m, tea.KeyTab)
+	assert.Contains(t, m.View(), root+"/project█")
+	assert.NotContains(t, m.View(), ".cache")
+}
@@ -0,0 +1,290 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/wake/tmux-session-menu/internal/store"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+)
+
+// Highlight 記錄列表中各欄位符合搜尋的 rune 位置。
+type Highlight struct {
+	Name       []int // session 名稱或群組名稱
+	CustomName []int
+	Model      []int
+	Group      []int // session 所屬群組的名稱，由 FilterItems 合併到群組標頭的 Name
+}
+
+// 模糊比對的計分。
+const (
+	scoreMatch       = 16 // 每個符合的字元
+	scoreConsecutive = 8  // 與前一個符合的字元相鄰
+	scoreBoundary    = 8  // 位於單字開頭（字串開頭或分隔字元之後）
+	scoreNameField   = 12 // 符合名稱（而非路徑、摘要等）
+	maxLeadingGap    = 6  // 第一個符合字元之前的字元最多扣這麼多分
+)
+
+// fuzzyMatch 以不分大小寫的子序列比對 pattern（已轉小寫）與 text，回傳分數與符合的 rune 位置。
+// 每個可能的起點各以貪婪方式比對一次，取分數最高者。
+func fuzzyMatch(pattern []rune, text string) (int, []int, bool) {
+	if len(pattern) == 0 {
+		return 0, nil, true
+	}
+	runes := []rune(text)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	bestScore, found := 0, false
+	var best []int
+	for start, r := range runes {
+		if r != pattern[0] {
+			continue
+		}
+		pos := []int{start}
+		for i, p := start+1, 1; i < len(runes) && p < len(pattern); i++ {
+			if runes[i] == pattern[p] {
+				pos = append(pos, i)
+				p++
+			}
+		}
+		if len(pos) < len(pattern) {
+			break // 之後的起點只會更少字元可比對
+		}
+		if score := matchScore(runes, pos); !found || score > bestScore {
+			bestScore, best, found = score, pos, true
+		}
+	}
+	return bestScore, best, found
+}
+
+// matchScore 依符合位置計分：相鄰與單字開頭加分，開頭前與字元間的間隔扣分。
+func matchScore(runes []rune, pos []int) int {
+	score := -min(pos[0], maxLeadingGap)
+	for i, p := range pos {
+		score += scoreMatch
+		if p == 0 || isSeparator(runes[p-1]) {
+			score += scoreBoundary
+		}
+		if i > 0 {
+			if p == pos[i-1]+1 {
+				score += scoreConsecutive
+			} else {
+				score -= p - pos[i-1] - 1
+			}
+		}
+	}
+	return score
+}
+
+// isSeparator 判斷 r 是否為單字分隔字元。
+func isSeparator(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("-_/.:", r)
+}
+
+// 可搜尋的 session 欄位。
+const (
+	fieldName = iota
+	fieldCustomName
+	fieldPath
+	fieldGroup
+	fieldModel
+	fieldSummary
+)
+
+// matchSession 比對 session 的名稱、自訂名稱、路徑、群組、AI 模型與摘要。
+// 查詢以空白分成多個詞，每個詞都必須符合其中一個欄位。
+func matchSession(s tmux.Session, terms [][]rune) (int, Highlight, bool) {
+	fields := [...]string{
+		fieldName:       s.Name,
+		fieldCustomName: s.CustomName,
+		fieldPath:       s.Path,
+		fieldGroup:      s.GroupName,
+		fieldModel:      s.AIModel,
+		fieldSummary:    s.AISummary,
+	}
+	total := 0
+	var hl Highlight
+	for _, term := range terms {
+		bestScore, bestField := 0, -1
+		var bestPos []int
+		for f, text := range fields {
+			score, pos, ok := fuzzyMatch(term, text)
+			if !ok {
+				continue
+			}
+			if f == fieldName || f == fieldCustomName {
+				score += scoreNameField
+			}
+			if bestField < 0 || score > bestScore {
+				bestScore, bestField, bestPos = score, f, pos
+			}
+		}
+		if bestField < 0 {
+			return 0, Highlight{}, false
+		}
+		total += bestScore
+		switch bestField {
+		case fieldName:
+			hl.Name = append(hl.Name, bestPos...)
+		case fieldCustomName:
+			hl.CustomName = append(hl.CustomName, bestPos...)
+		case fieldModel:
+			hl.Model = append(hl.Model, bestPos...)
+		case fieldGroup:
+			hl.Group = append(hl.Group, bestPos...)
+		}
+	}
+	return total, hl, true
+}
+
+// searchTerms 將查詢字串轉為小寫的搜尋詞。
+func searchTerms(query string) [][]rune {
+	var terms [][]rune
+	for _, t := range strings.Fields(strings.ToLower(query)) {
+		terms = append(terms, []rune(t))
+	}
+	return terms
+}
+
+// FilterItems 與 FlattenItems 相同，但只保留符合 query 的 session，並依分數由高到低排列。
+// 只保留仍有符合 session 的群組標頭，且不論是否收合都展開顯示；
+// 以群組名稱符合的字元標示在群組標頭上。
+func FilterItems(groups []store.Group, sessions []tmux.Session, query string) []ListItem {
+	terms := searchTerms(query)
+	byGroup := make(map[string][]ListItem)
+	for _, s := range sessions {
+		score, hl, ok := matchSession(s, terms)
+		if !ok {
+			continue
+		}
+		byGroup[s.GroupName] = append(byGroup[s.GroupName], ListItem{
+			Type: ItemSession, Session: s, Score: score, Highlight: hl,
+		})
+	}
+	for _, hits := range byGroup {
+		sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	}
+
+	items := byGroup[""]
+	for _, g := range groups {
+		hits := byGroup[g.Name]
+		if len(hits) == 0 {
+			continue
+		}
+		g.Collapsed = false
+		var hl Highlight
+		for _, hit := range hits {
+			for _, p := range hit.Highlight.Group {
+				if !slices.Contains(hl.Name, p) {
+					hl.Name = append(hl.Name, p)
+				}
+			}
+		}
+		slices.Sort(hl.Name)
+		items = append(items, ListItem{Type: ItemGroup, Group: g, Highlight: hl})
+		items = append(items, hits...)
+	}
+	return items
+}
+
+// topHit 回傳分數最高的 session 項目位置，沒有 session 時回傳 -1。
+func topHit(items []ListItem) int {
+	best := -1
+	for i, item := range items {
+		if item.Type == ItemSession && (best < 0 || item.Score > items[best].Score) {
+			best = i
+		}
+	}
+	return best
+}
+
+// rebuildItems 依目前的群組、session 與搜尋字串重建列表。
+func (m *Model) rebuildItems() {
+	if m.search != nil && strings.TrimSpace(m.search.String()) != "" {
+		m.SetItems(FilterItems(m.groups, m.sessions, m.search.String()))
+		return
+	}
+	m.SetItems(FlattenItems(m.groups, m.sessions))
+}
+
+// updateSearch 處理搜尋模式的按鍵：輸入即時篩選並將游標移到最佳結果，
+// ↑↓ 在結果間移動，Enter 連線到游標所在的 session，Esc 結束搜尋並還原列表。
+func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "ctrl+p":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+		return m, m.previewCmd(false)
+	case "down", "ctrl+n":
+		if m.cursor < len(m.items)-1 {
+			m.cursor++
+		}
+		return m, m.previewCmd(false)
+	}
+
+	in := *m.search
+	switch in.update(msg) {
+	case inputSubmitted:
+		if item, ok := m.current(); ok && item.Type == ItemSession {
+			return m.activate()
+		}
+		return m, nil
+	case inputCanceled:
+		m.search = nil
+		m.rebuildItems()
+		return m, m.previewCmd(false)
+	}
+	m.search = &in
+	m.rebuildItems()
+	if top := topHit(m.items); top >= 0 {
+		m.cursor = top
+	}
+	return m, m.previewCmd(false)
+}
+
+// matchCount 回傳搜尋結果的 session 數量說明。
+func (m Model) matchCount() string {
+	n := 0
+	for _, item := range m.items {
+		if item.Type == ItemSession {
+			n++
+		}
+	}
+	return fmt.Sprintf("%d/%d", n, len(m.sessions))
+}
+
+// highlight 以 style 渲染 text，並以 matchStyle 標示 positions 中的 rune。
+func highlight(text string, positions []int, style lipgloss.Style) string {
+	if len(positions) == 0 {
+		return style.Render(text)
+	}
+	var b, run strings.Builder
+	matched := false
+	flush := func() {
+		if run.Len() == 0 {
+			return
+		}
+		if matched {
+			b.WriteString(matchStyle.Inherit(style).Render(run.String()))
+		} else {
+			b.WriteString(style.Render(run.String()))
+		}
+		run.Reset()
+	}
+	for i, r := range []rune(text) {
+		if hit := slices.Contains(positions, i); hit != matched {
+			flush()
+			matched = hit
+		}
+		run.WriteRune(r)
+	}
+	flush()
+	return b.String()
+}
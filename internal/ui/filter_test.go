@@ -0,0 +1,103 @@
+package ui_test
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wake/tmux-session-menu/internal/store"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+	"github.com/wake/tmux-session-menu/internal/ui"
+)
+
+var filterGroups = []store.Group{{ID: 1, Name: "backend", Collapsed: true}, {ID: 2, Name: "frontend"}}
+
+var filterSessions = []tmux.Session{
+	{Name: "workflow"},
+	{Name: "infra", Path: "/home/u/terraform", AIModel: "claude-opus-4"},
+	{Name: "notes", CustomName: "Meeting notes"},
+	{Name: "api-server", GroupName: "backend"},
+	{Name: "web-frontend", GroupName: "frontend"},
+}
+
+// itemNames 回傳列表項目的名稱，群組標頭以 "#" 開頭。
+func itemNames(items []ui.ListItem) []string {
+	var names []string
+	for _, item := range items {
+		if item.Type == ui.ItemGroup {
+			names = append(names, "#"+item.Group.Name)
+		} else {
+			names = append(names, item.Session.Name)
+		}
+	}
+	return names
+}
+
+func TestFilterItems(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"api", []string{"#backend", "api-server"}},
+		{"opus", []string{"infra"}},
+		{"terra", []string{"infra"}},
+		{"meet", []string{"notes"}},
+		{"back srv", []string{"#backend", "api-server"}},
+		{"WF", []string{"workflow", "#frontend", "web-frontend"}},
+		{"zzz", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.want, itemNames(ui.FilterItems(filterGroups, filterSessions, tt.query)))
+		})
+	}
+}
+
+func TestFilterItems_Highlight(t *testing.T) {
+	items := ui.FilterItems(filterGroups, filterSessions, "asv")
+	require.Len(t, items, 2)
+	assert.False(t, items[0].Group.Collapsed, "有符合結果的群組一律展開")
+	assert.Equal(t, []int{0, 4, 7}, items[1].Highlight.Name)
+
+	items = ui.FilterItems(filterGroups, filterSessions, "opus")
+	assert.Equal(t, []int{7, 8, 9, 10}, items[0].Highlight.Model)
+
+	// 以群組名稱符合時標示在群組標頭
+	items = ui.FilterItems(filterGroups, filterSessions, "back srv")
+	require.Len(t, items, 2)
+	assert.Equal(t, []int{0, 1, 2, 3}, items[0].Highlight.Name)
+	assert.Equal(t, []int{4, 6, 7}, items[1].Highlight.Name)
+}
+
+func TestFilterItems_RanksBoundaryMatches(t *testing.T) {
+	sessions := []tmux.Session{{Name: "my-website"}, {Name: "swe-bench"}, {Name: "web"}}
+	assert.Equal(t, []string{"web", "my-website", "swe-bench"}, itemNames(ui.FilterItems(nil, sessions, "web")))
+}
+
+func TestModel_Search(t *testing.T) {
+	m := ui.NewModel(ui.Deps{})
+	updated, _ := m.Update(ui.SessionsMsg{Groups: filterGroups, Sessions: filterSessions})
+	m = updated.(ui.Model)
+
+	m, _ = applyKey(m, "/")
+	m, _ = applyKey(m, "w")
+	m, _ = applyKey(m, "e")
+	view := m.View()
+	assert.Contains(t, view, "/ we")
+	assert.Contains(t, view, "frontend")
+	assert.NotContains(t, view, "infra")
+	assert.NotContains(t, view, "backend")
+
+	// Esc 結束搜尋並還原列表
+	m, _ = applySpecialKey(m, tea.KeyEsc)
+	assert.Contains(t, m.View(), "infra")
+	assert.NotEmpty(t, m.View())
+
+	// Enter 連線到最佳結果
+	m, _ = applyKey(m, "/")
+	m, _ = applyKey(m, "nfr")
+	m, cmd := applySpecialKey(m, tea.KeyEnter)
+	assert.Equal(t, "infra", m.Selected())
+	assert.NotNil(t, cmd)
+}
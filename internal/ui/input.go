@@ -0,0 +1,51 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// lineInput 是單行文字輸入框。
+type lineInput struct {
+	prompt string
+	value  []rune
+}
+
+// inputResult 是 lineInput 處理按鍵後的結果。
+type inputResult int
+
+const (
+	inputEditing   inputResult = iota // 仍在輸入
+	inputSubmitted                    // 按下 Enter
+	inputCanceled                     // 按下 Esc
+)
+
+// update 處理一個按鍵。
+func (in *lineInput) update(msg tea.KeyMsg) inputResult {
+	switch msg.Type {
+	case tea.KeyEnter:
+		return inputSubmitted
+	case tea.KeyEsc, tea.KeyCtrlC:
+		return inputCanceled
+	case tea.KeyBackspace:
+		if len(in.value) > 0 {
+			in.value = in.value[:len(in.value)-1]
+		}
+	case tea.KeyCtrlU:
+		in.value = nil
+	case tea.KeySpace:
+		in.value = append(in.value, ' ')
+	case tea.KeyRunes:
+		in.value = append(in.value, msg.Runes...)
+	}
+	return inputEditing
+}
+
+// String 回傳目前輸入的文字。
+func (in *lineInput) String() string {
+	return string(in.value)
+}
+
+// View 渲染輸入框（含游標）。
+func (in *lineInput) View() string {
+	return selectedStyle.Render(in.prompt) + " " + string(in.value) + selectedStyle.Render("█")
+}
@@ -1,6 +1,8 @@
 package ui
 
 import (
+	"strconv"
+
 	"github.com/wake/tmux-session-menu/internal/store"
 	"github.com/wake/tmux-session-menu/internal/tmux"
 )
@@ -15,9 +17,23 @@ const (
 
 // ListItem 代表列表中的一個項目（session 或群組標頭）。
 type ListItem struct {
-	Type    ItemType
-	Session tmux.Session
-	Group   store.Group
+	Type      ItemType
+	Session   tmux.Session
+	Group     store.Group
+	Score     int       // 搜尋分數（未搜尋時為 0）
+	Highlight Highlight // 搜尋時符合的字元位置
+}
+
+// key 回傳項目的識別字串，用於重新整理列表時保留游標位置。
+// session 以 id 識別，重新命名後游標仍停在同一個 session；沒有 id 時退回名稱。
+func (item ListItem) key() string {
+	if item.Type == ItemGroup {
+		return "g:" + strconv.FormatInt(item.Group.ID, 10)
+	}
+	if item.Session.ID != "" {
+		return "s:" + item.Session.ID
+	}
+	return "n:" + item.Session.Name
 }
 
 // FlattenItems 將群組與 session 扁平化為一維列表。
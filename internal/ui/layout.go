@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/wake/tmux-session-menu/internal/store"
+)
+
+const (
+	splitSettingKey = "layout.split_percent" // store 中記錄分割比例的設定鍵
+	defaultSplit    = 40                     // 列表預設佔畫面寬度的百分比
+	minSplit        = 20
+	maxSplit        = 80
+	splitStep       = 5
+)
+
+// loadSplit 從 store 讀取上次的分割比例，沒有紀錄或格式錯誤時使用預設值。
+func loadSplit(st *store.Store) int {
+	if st == nil {
+		return defaultSplit
+	}
+	value, ok, err := st.Setting(splitSettingKey)
+	if err != nil || !ok {
+		return defaultSplit
+	}
+	split, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultSplit
+	}
+	return min(max(split, minSplit), maxSplit)
+}
+
+// adjustSplit 調整列表所佔的寬度比例並寫回 store。
+func (m Model) adjustSplit(delta int) (tea.Model, tea.Cmd) {
+	split := min(max(m.split+delta, minSplit), maxSplit)
+	if split == m.split {
+		return m, nil
+	}
+	m.split = split
+	if m.deps.Store != nil {
+		if err := m.deps.Store.SetSetting(splitSettingKey, strconv.Itoa(split)); err != nil {
+			m.err = err
+		}
+	}
+	return m, nil
+}
+
+// viewWidth 回傳畫面寬度，尚未收到視窗大小時使用預設值。
+func (m Model) viewWidth() int {
+	if m.width <= 0 {
+		return defaultViewWidth
+	}
+	return m.width
+}
+
+// sideBySide 回傳是否以左右並排顯示：終端寬度超過設定的門檻時啟用。
+func (m Model) sideBySide() bool {
+	threshold := m.deps.Cfg.SideBySideWidth
+	return threshold > 0 && m.width > threshold
+}
+
+// viewSideBySide 以左側列表、右側預覽的版面渲染畫面。
+func (m Model) viewSideBySide() string {
+	listWidth := m.width * m.split / 100
+	// 先截斷過長的列再補齊寬度，避免 Width 自動換行打亂列表
+	list := strings.TrimSuffix(m.renderList(), "\n")
+	list = lipgloss.NewStyle().Width(listWidth).Render(lipgloss.NewStyle().MaxWidth(listWidth).Render(list))
+
+	// 右側扣除框線與內距
+	previewWidth := m.width - listWidth - previewSideStyle.GetHorizontalFrameSize()
+	height := max(m.height, previewMinHeight)
+	preview := m.renderPreview(previewWidth, height, previewSideStyle.Height(height))
+	return lipgloss.JoinHorizontal(lipgloss.Top, list, preview)
+}
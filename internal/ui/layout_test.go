@@ -0,0 +1,77 @@
+package ui_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wake/tmux-session-menu/internal/config"
+	"github.com/wake/tmux-session-menu/internal/store"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+	"github.com/wake/tmux-session-menu/internal/ui"
+)
+
+// resized 回傳套用視窗大小後的 model。
+func resized(m ui.Model, width, height int) ui.Model {
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: width, Height: height})
+	return updated.(ui.Model)
+}
+
+func TestModel_View_SideBySide(t *testing.T) {
+	exec := &fakeExecutor{outputs: map[string]string{
+		"capture-pane -t %1 -p -e -S -150": "$ make test\nPASS",
+	}}
+	m := ui.NewModel(ui.Deps{TmuxMgr: tmux.NewManager(exec), Cfg: config.Default()})
+	m = resized(m, 160, 30)
+	updated, cmd := m.Update(ui.SessionsMsg{Sessions: previewSessions()})
+	m = runCmd(t, updated.(ui.Model), cmd)
+
+	lines := strings.Split(m.View(), "\n")
+	assert.Len(t, lines, 30)
+	// 第一列同時有標題與右側預覽的標題
+	assert.Contains(t, lines[0], "tmux session menu")
+	assert.Contains(t, lines[0], "│ alpha")
+	assert.Contains(t, lines[1], "$ make test")
+	for _, l := range lines {
+		assert.LessOrEqual(t, lipgloss.Width(l), 160)
+	}
+
+	// 低於門檻時改回上下排列
+	m = resized(m, 100, 30)
+	lines = strings.Split(m.View(), "\n")
+	assert.NotContains(t, lines[0], "│")
+	assert.Contains(t, m.View(), "$ make test")
+}
+
+func TestModel_AdjustSplit_Persists(t *testing.T) {
+	st, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { st.Close() })
+
+	m := ui.NewModel(ui.Deps{Store: st, Cfg: config.Default()})
+	m = resized(m, 200, 20)
+	m.SetItems([]ui.ListItem{{Type: ui.ItemSession, Session: tmux.Session{Name: "alpha"}}})
+	before := strings.Index(strings.Split(m.View(), "\n")[0], "│")
+
+	m, _ = applyKey(m, ">")
+	m, _ = applyKey(m, ">")
+	after := strings.Index(strings.Split(m.View(), "\n")[0], "│")
+	assert.Greater(t, after, before)
+
+	value, ok, err := st.Setting("layout.split_percent")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "50", value)
+
+	// 重新開啟時沿用上次的比例，並夾在允許範圍內
+	m = resized(ui.NewModel(ui.Deps{Store: st, Cfg: config.Default()}), 200, 20)
+	for range 20 {
+		m, _ = applyKey(m, "<")
+	}
+	value, _, _ = st.Setting("layout.split_percent")
+	assert.Equal(t, "20", value)
+}
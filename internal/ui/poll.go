@@ -0,0 +1,277 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/wake/tmux-session-menu/internal/ai"
+	"github.com/wake/tmux-session-menu/internal/config"
+	"github.com/wake/tmux-session-menu/internal/store"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+)
+
+// eventDebounce 是收到 tmux 結構變更通知後合併等待的時間，避免連續通知造成頻繁重新載入。
+const eventDebounce = 300 * time.Millisecond
+
+// tickMsg 是輪詢計時器觸發時送出的訊息。
+type tickMsg time.Time
+
+// eventMsg 包裝一則 control mode 通知。
+type eventMsg tmux.Notification
+
+// HookMsg 包裝一則 hook 狀態檔案變更。
+type HookMsg tmux.HookUpdate
+
+// refreshMsg 是通知合併等待結束、應重新載入時送出的訊息。
+type refreshMsg struct{}
+
+// SessionsMsg 攜帶一次輪詢所載入的群組與 session 資料。
+type SessionsMsg struct {
+	Groups   []store.Group
+	Sessions []tmux.Session
+	Err      error
+}
+
+// tickCmd 在 d 之後送出 tickMsg。
+func tickCmd(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+// waitEventCmd 等待下一則 control mode 通知；channel 關閉後不再送出訊息。
+func waitEventCmd(events <-chan tmux.Notification) tea.Cmd {
+	return func() tea.Msg {
+		n, ok := <-events
+		if !ok {
+			return nil
+		}
+		return eventMsg(n)
+	}
+}
+
+// waitHookCmd 等待下一則 hook 狀態變更；channel 關閉後不再送出訊息。
+func waitHookCmd(updates <-chan tmux.HookUpdate) tea.Cmd {
+	return func() tea.Msg {
+		u, ok := <-updates
+		if !ok {
+			return nil
+		}
+		return HookMsg(u)
+	}
+}
+
+// refreshCmd 在 eventDebounce 之後送出 refreshMsg。
+func refreshCmd() tea.Cmd {
+	return tea.Tick(eventDebounce, func(time.Time) tea.Msg { return refreshMsg{} })
+}
+
+// loadSessionsCmd 在背景執行 LoadSessions。
+func loadSessionsCmd(deps Deps) tea.Cmd {
+	return func() tea.Msg { return LoadSessions(deps) }
+}
+
+// pollInterval 回傳設定的輪詢間隔，未設定時使用預設值。
+func (m Model) pollInterval() time.Duration {
+	sec := m.deps.Cfg.PollIntervalSec
+	if sec <= 0 {
+		sec = config.Default().PollIntervalSec
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// LoadSessions 列出所有 tmux session，解析三層狀態，並合併 store 中的群組與排序資訊。
+func LoadSessions(deps Deps) SessionsMsg {
+	sessions, err := deps.TmuxMgr.ListSessions()
+	if err != nil {
+		return SessionsMsg{Err: err}
+	}
+
+	// pane 列表無法取得或解析時回報錯誤，不退回以 session 為單位偵測而默默失去各 pane 的狀態
+	panes, err := deps.TmuxMgr.ListPanes()
+	if err != nil {
+		return SessionsMsg{Err: fmt.Errorf("list panes: %w", err)}
+	}
+	bySession := make(map[string][]tmux.Pane)
+	for _, p := range panes {
+		bySession[p.SessionName] = append(bySession[p.SessionName], p)
+	}
+
+	now := time.Now()
+	var tracked []tmux.TrackerKey
+	for i := range sessions {
+		s := &sessions[i]
+		var hook *tmux.HookStatus
+		if hs, err := tmux.ReadHookStatus(deps.Cfg.StatusDir(), s.ID); err == nil {
+			hook = &hs
+		}
+
+		sessionPanes := bySession[s.Name]
+		// 兩次列出之間才建立的 session 還沒有 pane 資料，先以 session 為單位擷取 active pane
+		if len(sessionPanes) == 0 {
+			sessionPanes = []tmux.Pane{{ID: s.Name, SessionName: s.Name, Active: true}}
+		}
+		for j := range sessionPanes {
+			p := &sessionPanes[j]
+			resolvePane(deps, p, hook)
+			if deps.Tracker != nil {
+				key := tmux.TrackerKey{Session: s.ID, Pane: p.ID}
+				if p.Source == tmux.SourceHook {
+					deps.Tracker.Set(key, p.Status, p.Source, now)
+				} else {
+					p.Status = deps.Tracker.Update(key, p.Status, p.Source, now)
+				}
+				_, p.StatusSince, _ = deps.Tracker.Status(key)
+				tracked = append(tracked, key)
+			}
+			if p.Status != tmux.StatusWaiting {
+				p.Wait = tmux.WaitInfo{}
+			}
+			s.Wait = tmux.MostUrgentWait(s.Wait, p.Wait)
+		}
+
+		s.Windows = tmux.GroupWindows(sessionPanes)
+		for _, w := range s.Windows {
+			s.Status = tmux.RollupStatus(s.Status, w.Status)
+		}
+		s.Agent, s.AIModel = primaryAgent(sessionPanes)
+	}
+	if deps.Tracker != nil {
+		deps.Tracker.Retain(tracked, now)
+		recordTransitions(deps, sessions, deps.Tracker.Drain())
+	}
+
+	var groups []store.Group
+	if deps.Store != nil {
+		live := make([]store.LiveSession, len(sessions))
+		for i, s := range sessions {
+			live[i] = store.LiveSession{ID: s.ID, Name: s.Name}
+		}
+		// 無法取得 server 識別時仍可對應重新命名，只是不會偵測 server 重新啟動
+		server, _ := deps.TmuxMgr.ServerID()
+		if err := deps.Store.ReconcileSessions(server, live); err != nil {
+			return SessionsMsg{Err: err}
+		}
+		groups, err = deps.Store.ListGroups()
+		if err != nil {
+			return SessionsMsg{Err: err}
+		}
+		metas, err := deps.Store.ListAllSessionMetas()
+		if err != nil {
+			return SessionsMsg{Err: err}
+		}
+		applyMetas(sessions, groups, metas)
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		if sessions[i].SortOrder != sessions[j].SortOrder {
+			return sessions[i].SortOrder < sessions[j].SortOrder
+		}
+		return sessions[i].Name < sessions[j].Name
+	})
+
+	return SessionsMsg{Groups: groups, Sessions: sessions}
+}
+
+// resolvePane 擷取 pane 內容並以三層偵測判斷其狀態、偵測層、等待原因與 AI 模型。
+// hook 狀態只套用在寫入它的 pane（舊格式未記錄 pane 時套用到所有 pane）。
+func resolvePane(deps Deps, p *tmux.Pane, hook *tmux.HookStatus) {
+	scr, content, _ := deps.TmuxMgr.CaptureScreen(*p, deps.Cfg.PreviewLines)
+
+	input := tmux.StatusInput{PaneTitle: p.Title, PaneContent: content, PaneCommand: p.Command, Screen: scr}
+	if hook != nil && hook.AppliesTo(p.ID) {
+		input.HookStatus = hook
+	}
+	res := tmux.Resolve(input)
+	p.Status, p.Source, p.Wait = res.Status, res.Source, res.Wait
+
+	agentInput := tmux.AgentInput{Command: p.Command, Title: p.Title, Content: content}
+	p.Agent = ai.DetectPaneTool(agentInput)
+	p.AIModel = ai.DetectPaneModel(agentInput)
+}
+
+// recordTransitions 將狀態轉換寫入歷史紀錄。hook 造成的轉換已由 tsm hook 寫入，不重複記錄。
+// 轉換以 session id 追蹤，寫入時才換成名稱：執行中的 session 取目前的名稱，
+// 已結束的 session 取 store 記錄的上次名稱（此時尚未 ReconcileSessions），找不到時略過。
+// 寫入失敗不影響畫面更新。
+func recordTransitions(deps Deps, sessions []tmux.Session, transitions []tmux.Transition) {
+	if deps.Store == nil || len(transitions) == 0 {
+		return
+	}
+	names := make(map[string]string, len(sessions))
+	for _, s := range sessions {
+		names[s.ID] = s.Name
+	}
+	var known map[string]string
+	var events []store.StatusEvent
+	for _, t := range transitions {
+		if t.Source == tmux.SourceHook {
+			continue
+		}
+		name, ok := names[t.Key.Session]
+		if !ok {
+			if known == nil {
+				if known, _ = deps.Store.SessionNames(); known == nil {
+					known = map[string]string{}
+				}
+			}
+			if name, ok = known[t.Key.Session]; !ok {
+				continue
+			}
+		}
+		events = append(events, store.StatusEvent{
+			SessionName: name,
+			PaneID:      t.Key.Pane,
+			OldStatus:   t.From.String(),
+			NewStatus:   t.To.String(),
+			Source:      string(t.Source),
+			At:          t.At,
+		})
+	}
+	_ = deps.Store.RecordStatusEvents(events)
+}
+
+// primaryAgent 回傳 session 的 agent 與 AI 模型，優先採用 active pane 偵測到的結果。
+func primaryAgent(panes []tmux.Pane) (agent, model string) {
+	for _, p := range panes {
+		if p.Agent == "" && p.AIModel == "" {
+			continue
+		}
+		if p.Active {
+			return p.Agent, p.AIModel
+		}
+		if agent == "" && model == "" {
+			agent, model = p.Agent, p.AIModel
+		}
+	}
+	return agent, model
+}
+
+// applyMetas 將 store 中的群組名稱與排序寫入對應的 session，依 session id 對應（尚未記錄 id 時依名稱）。
+func applyMetas(sessions []tmux.Session, groups []store.Group, metas []store.SessionMeta) {
+	groupNames := make(map[int64]string, len(groups))
+	for _, g := range groups {
+		groupNames[g.ID] = g.Name
+	}
+	byID := make(map[string]store.SessionMeta, len(metas))
+	byName := make(map[string]store.SessionMeta, len(metas))
+	for _, meta := range metas {
+		if meta.SessionID != "" {
+			byID[meta.SessionID] = meta
+		} else {
+			byName[meta.SessionName] = meta
+		}
+	}
+	for i := range sessions {
+		meta, ok := byID[sessions[i].ID]
+		if !ok {
+			meta, ok = byName[sessions[i].Name]
+		}
+		if !ok {
+			continue
+		}
+		sessions[i].GroupName = groupNames[meta.GroupID]
+		sessions[i].SortOrder = meta.SortOrder
+		sessions[i].CustomName = meta.CustomName
+	}
+}
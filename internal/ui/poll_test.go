@@ -0,0 +1,288 @@
+package ui_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wake/tmux-session-menu/internal/config"
+	"github.com/wake/tmux-session-menu/internal/store"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+	"github.com/wake/tmux-session-menu/internal/ui"
+)
+
+// fakeExecutor 依參數字串回傳預設輸出，模擬 tmux 指令，並記錄所有被執行的指令。
+type fakeExecutor struct {
+	outputs map[string]string
+	calls   []string
+}
+
+func (f *fakeExecutor) Execute(args ...string) (string, error) {
+	key := strings.Join(args, " ")
+	f.calls = append(f.calls, key)
+	return f.outputs[key], nil
+}
+
+func TestLoadSessions(t *testing.T) {
+	exec := &fakeExecutor{outputs: map[string]string{
+		"list-sessions -F " + tmux.ListSessionsFormat: "beta:$1:1:/tmp:0:1709312400\nalpha:$2:1:/tmp:1:1709312400",
+		"list-panes -a -F " + tmux.ListPanesFormat: "beta\t0\tmain\t0\t%1\t11\tclaude\t1\t80\t24\t0\t0\t⠋ Working\n" +
+			"alpha\t0\tmain\t0\t%2\t12\tzsh\t1\t80\t24\t0\t0\tzsh\n" +
+			"alpha\t1\tagent\t0\t%3\t13\tclaude\t1\t80\t24\t0\t0\tClaude",
+		"capture-pane -t %2 -p -e -S -150": "user@host:~$",
+		"capture-pane -t %3 -p -e -S -150": "\033[2mclaude-sonnet-4-6\033[0m\n❯",
+	}}
+
+	st, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { st.Close() })
+	require.NoError(t, st.CreateGroup("dev", 0))
+	groups, _ := st.ListGroups()
+	require.NoError(t, st.SetSessionGroup("beta", groups[0].ID, 0))
+
+	cfg := config.Default()
+	cfg.DataDir = t.TempDir()
+	msg := ui.LoadSessions(ui.Deps{TmuxMgr: tmux.NewManager(exec), Store: st, Cfg: cfg})
+
+	require.NoError(t, msg.Err)
+	require.Len(t, msg.Sessions, 2)
+	assert.Len(t, msg.Groups, 1)
+
+	assert.Equal(t, "alpha", msg.Sessions[0].Name)
+	assert.Equal(t, tmux.StatusWaiting, msg.Sessions[0].Status)
+	assert.Equal(t, tmux.ReasonPrompt, msg.Sessions[0].Wait.Reason)
+	assert.Equal(t, "claude-sonnet-4-6", msg.Sessions[0].AIModel)
+	assert.Equal(t, "", msg.Sessions[0].GroupName)
+	require.Len(t, msg.Sessions[0].Windows, 2)
+	assert.Equal(t, tmux.StatusIdle, msg.Sessions[0].Windows[0].Status)
+	assert.Equal(t, tmux.StatusWaiting, msg.Sessions[0].Windows[1].Status)
+	assert.Equal(t, "%3", msg.Sessions[0].Windows[1].Panes[0].ID)
+
+	assert.Equal(t, "beta", msg.Sessions[1].Name)
+	assert.Equal(t, tmux.StatusRunning, msg.Sessions[1].Status)
+	assert.Equal(t, "dev", msg.Sessions[1].GroupName)
+
+	// 每個 pane 只擷取一次
+	var captures []string
+	for _, c := range exec.calls {
+		if strings.HasPrefix(c, "capture-pane") {
+			captures = append(captures, c)
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		"capture-pane -t %1 -p -e -S -150",
+		"capture-pane -t %2 -p -e -S -150",
+		"capture-pane -t %3 -p -e -S -150",
+	}, captures)
+}
+
+func TestLoadSessions_FollowsRename(t *testing.T) {
+	exec := &fakeExecutor{outputs: map[string]string{
+		"list-sessions -F " + tmux.ListSessionsFormat: "api:$4:1:/tmp:0:1709312400",
+		"display-message -p #{pid}:#{start_time}":     "100:1709312000",
+	}}
+	st, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { st.Close() })
+	require.NoError(t, st.AssignSessionGroup("api", "dev", 0))
+
+	cfg := config.Default()
+	cfg.DataDir = t.TempDir()
+	deps := ui.Deps{TmuxMgr: tmux.NewManager(exec), Store: st, Cfg: cfg}
+	msg := ui.LoadSessions(deps)
+	require.NoError(t, msg.Err)
+	assert.Equal(t, "dev", msg.Sessions[0].GroupName)
+
+	// 在 tsm 之外重新命名，session id 不變
+	exec.outputs["list-sessions -F "+tmux.ListSessionsFormat] = "backend:$4:1:/tmp:0:1709312400"
+	msg = ui.LoadSessions(deps)
+	require.NoError(t, msg.Err)
+	require.Len(t, msg.Sessions, 1)
+	assert.Equal(t, "backend", msg.Sessions[0].Name)
+	assert.Equal(t, "dev", msg.Sessions[0].GroupName)
+}
+
+func TestLoadSessions_ListPanesError(t *testing.T) {
+	exec := &fakeExecutor{outputs: map[string]string{
+		"list-sessions -F " + tmux.ListSessionsFormat: "work:$1:1:/tmp:0:1709312400",
+		// 例如 tmux 將 tab 改為 _ 時無法解析
+		"list-panes -a -F " + tmux.ListPanesFormat: "work_0_main_0_%1_11_claude_1_80_24_0_0_Claude",
+	}}
+	cfg := config.Default()
+	cfg.DataDir = t.TempDir()
+
+	msg := ui.LoadSessions(ui.Deps{TmuxMgr: tmux.NewManager(exec), Cfg: cfg})
+	require.Error(t, msg.Err)
+	assert.Contains(t, msg.Err.Error(), "list panes")
+	assert.Empty(t, msg.Sessions)
+}
+
+func TestLoadSessions_HookAppliesToPane(t *testing.T) {
+	exec := &fakeExecutor{outputs: map[string]string{
+		"list-sessions -F " + tmux.ListSessionsFormat: "work:$1:2:/tmp:0:1709312400",
+		"list-panes -a -F " + tmux.ListPanesFormat: "work\t0\tmain\t0\t%1\t11\tclaude\t1\t80\t24\t0\t0\tClaude\n" +
+			"work\t1\tserver\t0\t%2\t12\tnpm\t1\t80\t24\t0\t0\tnpm",
+	}}
+
+	cfg := config.Default()
+	cfg.DataDir = t.TempDir()
+	hs := tmux.NewHookStatus("UserPromptSubmit")
+	hs.Pane = "%1"
+	require.NoError(t, tmux.WriteHookStatus(cfg.StatusDir(), "$1", hs))
+
+	msg := ui.LoadSessions(ui.Deps{TmuxMgr: tmux.NewManager(exec), Cfg: cfg})
+	require.NoError(t, msg.Err)
+	require.Len(t, msg.Sessions, 1)
+
+	windows := msg.Sessions[0].Windows
+	require.Len(t, windows, 2)
+	assert.Equal(t, tmux.StatusRunning, windows[0].Status)
+	assert.Equal(t, tmux.StatusIdle, windows[1].Status)
+	assert.Equal(t, tmux.StatusRunning, msg.Sessions[0].Status)
+}
+
+func TestLoadSessions_Debounce(t *testing.T) {
+	exec := &fakeExecutor{outputs: map[string]string{
+		"list-sessions -F " + tmux.ListSessionsFormat: "work:$1:1:/tmp:0:1709312400",
+		"list-panes -a -F " + tmux.ListPanesFormat:    "work\t0\tmain\t0\t%1\t11\tclaude\t1\t80\t24\t0\t0\tClaude",
+		"capture-pane -t %1 -p -e -S -150":            "✻ Thinking… (esc to interrupt)",
+	}}
+	cfg := config.Default()
+	cfg.DataDir = t.TempDir()
+	deps := ui.Deps{TmuxMgr: tmux.NewManager(exec), Cfg: cfg, Tracker: tmux.NewStatusTracker(cfg.StatusHold())}
+
+	msg := ui.LoadSessions(deps)
+	require.Len(t, msg.Sessions, 1)
+	assert.Equal(t, tmux.StatusRunning, msg.Sessions[0].Status)
+	since := msg.Sessions[0].Windows[0].Panes[0].StatusSince
+	assert.False(t, since.IsZero())
+
+	// spinner 換幀時短暫看似閒置，仍維持 running
+	exec.outputs["capture-pane -t %1 -p -e -S -150"] = "Done."
+	msg = ui.LoadSessions(deps)
+	assert.Equal(t, tmux.StatusRunning, msg.Sessions[0].Status)
+	assert.Equal(t, since, msg.Sessions[0].Windows[0].Panes[0].StatusSince)
+
+	// 沒有 Tracker 時直接採用偵測結果
+	deps.Tracker = nil
+	msg = ui.LoadSessions(deps)
+	assert.Equal(t, tmux.StatusIdle, msg.Sessions[0].Status)
+}
+
+func TestLoadSessions_TrackerFollowsRename(t *testing.T) {
+	exec := &fakeExecutor{outputs: map[string]string{
+		"list-sessions -F " + tmux.ListSessionsFormat: "work:$1:1:/tmp:0:1709312400",
+		"list-panes -a -F " + tmux.ListPanesFormat:    "work\t0\tmain\t0\t%1\t11\tclaude\t1\t80\t24\t0\t0\tClaude",
+		"capture-pane -t %1 -p -e -S -150":            "✻ Thinking… (esc to interrupt)",
+	}}
+	st, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { st.Close() })
+	cfg := config.Default()
+	cfg.DataDir = t.TempDir()
+	deps := ui.Deps{TmuxMgr: tmux.NewManager(exec), Store: st, Cfg: cfg, Tracker: tmux.NewStatusTracker(cfg.StatusHold())}
+
+	msg := ui.LoadSessions(deps)
+	require.Len(t, msg.Sessions, 1)
+	since := msg.Sessions[0].Windows[0].Panes[0].StatusSince
+
+	// 重新命名後仍是同一個追蹤鍵：防抖動不重新計算，也不記錄 ended
+	exec.outputs["list-sessions -F "+tmux.ListSessionsFormat] = "api:$1:1:/tmp:0:1709312400"
+	exec.outputs["list-panes -a -F "+tmux.ListPanesFormat] = "api\t0\tmain\t0\t%1\t11\tclaude\t1\t80\t24\t0\t0\tClaude"
+	exec.outputs["capture-pane -t %1 -p -e -S -150"] = "Done."
+	msg = ui.LoadSessions(deps)
+	require.Len(t, msg.Sessions, 1)
+	assert.Equal(t, tmux.StatusRunning, msg.Sessions[0].Status)
+	assert.Equal(t, since, msg.Sessions[0].Windows[0].Panes[0].StatusSince)
+
+	for _, name := range []string{"work", "api"} {
+		events, err := st.ListStatusEvents(name, time.Time{})
+		require.NoError(t, err)
+		assert.Empty(t, events, name)
+	}
+
+	// session 結束時以最後的名稱記錄 ended
+	exec.outputs["list-sessions -F "+tmux.ListSessionsFormat] = ""
+	exec.outputs["list-panes -a -F "+tmux.ListPanesFormat] = ""
+	ui.LoadSessions(deps)
+	events, err := st.ListStatusEvents("api", time.Time{})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "running", events[0].OldStatus)
+	assert.Equal(t, "ended", events[0].NewStatus)
+}
+
+func TestLoadSessions_RecordsTransitions(t *testing.T) {
+	exec := &fakeExecutor{outputs: map[string]string{
+		"list-sessions -F " + tmux.ListSessionsFormat: "work:$1:1:/tmp:0:1709312400",
+		"list-panes -a -F " + tmux.ListPanesFormat:    "work\t0\tmain\t0\t%1\t11\tclaude\t1\t80\t24\t0\t0\tClaude",
+		"capture-pane -t %1 -p -e -S -150":            "Done.",
+	}}
+	st, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { st.Close() })
+
+	cfg := config.Default()
+	cfg.DataDir = t.TempDir()
+	deps := ui.Deps{TmuxMgr: tmux.NewManager(exec), Store: st, Cfg: cfg, Tracker: tmux.NewStatusTracker(nil)}
+
+	ui.LoadSessions(deps)
+	exec.outputs["capture-pane -t %1 -p -e -S -150"] = "Allow?\n  Yes, allow once"
+	ui.LoadSessions(deps)
+
+	// hook 造成的轉換由 tsm hook 記錄，這裡不重複寫入
+	hs := tmux.NewHookStatus("UserPromptSubmit")
+	hs.Pane = "%1"
+	require.NoError(t, tmux.WriteHookStatus(cfg.StatusDir(), "$1", hs))
+	ui.LoadSessions(deps)
+
+	events, err := st.ListStatusEvents("work", time.Time{})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "%1", events[0].PaneID)
+	assert.Equal(t, "idle", events[0].OldStatus)
+	assert.Equal(t, "waiting", events[0].NewStatus)
+	assert.Equal(t, "content", events[0].Source)
+}
+
+func TestModel_SessionsMsg_KeepsCursor(t *testing.T) {
+	m := ui.NewModel(ui.Deps{})
+	updated, _ := m.Update(ui.SessionsMsg{Sessions: []tmux.Session{
+		{Name: "a"}, {Name: "b"}, {Name: "c"},
+	}})
+	m = updated.(ui.Model)
+
+	m, _ = applyKey(m, "j")
+	m, _ = applyKey(m, "j")
+	assert.Equal(t, 2, m.Cursor())
+
+	// c 移到最前面，游標應跟著 c
+	updated, _ = m.Update(ui.SessionsMsg{Sessions: []tmux.Session{
+		{Name: "c"}, {Name: "a"}, {Name: "b"},
+	}})
+	m = updated.(ui.Model)
+	assert.Equal(t, 0, m.Cursor())
+
+	// c 消失時游標夾在範圍內
+	updated, _ = m.Update(ui.SessionsMsg{Sessions: []tmux.Session{{Name: "a"}}})
+	m = updated.(ui.Model)
+	assert.Equal(t, 0, m.Cursor())
+}
+
+func TestModel_HookMsg_UpdatesStatus(t *testing.T) {
+	m := ui.NewModel(ui.Deps{})
+	updated, _ := m.Update(ui.SessionsMsg{Sessions: []tmux.Session{
+		{Name: "work", ID: "$1", Status: tmux.StatusIdle},
+	}})
+	m = updated.(ui.Model)
+	assert.Contains(t, m.View(), "○")
+
+	hs := tmux.HookStatus{Status: tmux.StatusRunning, RawStatus: "running", Timestamp: time.Now().Unix()}
+	updated, _ = m.Update(ui.HookMsg{SessionID: "$1", Status: &hs})
+	m = updated.(ui.Model)
+	assert.Contains(t, m.View(), "●")
+	assert.NotContains(t, m.View(), "○")
+}
@@ -0,0 +1,210 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+)
+
+const (
+	previewTTL           = 2 * time.Second // 游標移動時，超過此時間的快取才重新擷取
+	previewMinHeight     = 3               // 預覽區最少顯示的列數（含標題）
+	previewDefaultHeight = 10              // 尚未收到視窗大小時的預覽列數（含標題）
+	previewMaxWidth      = 512             // 無法取得 pane 寬度時解析擷取內容的畫面寬度
+	defaultViewWidth     = 80              // 尚未收到視窗大小時的畫面寬度
+)
+
+// preview 是一個 session 最近一次擷取的畫面。
+type preview struct {
+	screen *tmux.Screen
+	at     time.Time
+}
+
+// previewMsg 是背景擷取預覽的結果。
+type previewMsg struct {
+	session string
+	screen  *tmux.Screen
+	at      time.Time
+	err     error
+}
+
+// loadPreviewCmd 在背景擷取 session 目標 pane 的內容（保留顏色），解析為畫面模型。
+func loadPreviewCmd(mgr *tmux.Manager, s tmux.Session, lines int) tea.Cmd {
+	target, width := previewTarget(s)
+	return func() tea.Msg {
+		out, err := mgr.CapturePaneANSI(target, lines)
+		if err != nil {
+			return previewMsg{session: s.Name, err: err}
+		}
+		scr := tmux.NewScreen(width, strings.Count(out, "\n")+1)
+		scr.LoadCapture(out)
+		return previewMsg{session: s.Name, screen: scr, at: time.Now()}
+	}
+}
+
+// previewTarget 回傳預覽要擷取的 pane 與其寬度（未知時為 previewMaxWidth）。
+func previewTarget(s tmux.Session) (string, int) {
+	target := s.TargetPane()
+	for _, w := range s.Windows {
+		for _, p := range w.Panes {
+			if p.ID == target && p.Width > 0 {
+				return target, p.Width
+			}
+		}
+	}
+	return target, previewMaxWidth
+}
+
+// previewCmd 在需要時擷取游標所在 session 的預覽。force 為 false 時沿用未過期的快取；
+// 同一個 session 同時只會有一個擷取在進行。
+func (m Model) previewCmd(force bool) tea.Cmd {
+	item, ok := m.current()
+	if !ok || item.Type != ItemSession || m.deps.TmuxMgr == nil {
+		return nil
+	}
+	name := item.Session.Name
+	if m.previewLoading[name] {
+		return nil
+	}
+	if p, ok := m.previews[name]; ok && !force && time.Since(p.at) < previewTTL {
+		return nil
+	}
+	m.previewLoading[name] = true
+	return loadPreviewCmd(m.deps.TmuxMgr, item.Session, m.deps.Cfg.PreviewLines)
+}
+
+// applyPreview 將擷取結果存入快取；失敗時保留舊的預覽。
+func (m Model) applyPreview(msg previewMsg) Model {
+	delete(m.previewLoading, msg.session)
+	if msg.err == nil {
+		m.previews[msg.session] = preview{screen: msg.screen, at: msg.at}
+	}
+	return m
+}
+
+// prunePreviews 移除已不存在的 session 的預覽快取。
+func (m Model) prunePreviews() {
+	alive := make(map[string]bool, len(m.sessions))
+	for _, s := range m.sessions {
+		alive[s.Name] = true
+	}
+	for name := range m.previews {
+		if !alive[name] {
+			delete(m.previews, name)
+		}
+	}
+}
+
+// renderPreview 以 style 渲染游標所在 session 的預覽區，內容寬 width 欄、高 height 列（含標題）。
+func (m Model) renderPreview(width, height int, style lipgloss.Style) string {
+	item, ok := m.current()
+	if !ok || item.Type != ItemSession {
+		return ""
+	}
+
+	title := item.Session.Name
+	if item.Session.AISummary != "" {
+		title = fmt.Sprintf("Preview: %s", item.Session.AISummary)
+	}
+	lines := []string{dimStyle.Render(runewidth.Truncate(title, width, "…"))}
+
+	p, ok := m.previews[item.Session.Name]
+	if !ok {
+		if m.deps.TmuxMgr != nil {
+			lines = append(lines, dimStyle.Render("loading…"))
+		}
+		return style.Render(strings.Join(lines, "\n"))
+	}
+	lines = append(lines, renderScreenTail(p.screen, width, height-1)...)
+	return style.Render(strings.Join(lines, "\n"))
+}
+
+// renderScreenTail 以 lipgloss 渲染畫面最後 rows 個非空白列，每列最多 width 欄。
+func renderScreenTail(scr *tmux.Screen, width, rows int) []string {
+	_, h := scr.Size()
+	end := h
+	for end > 0 && scr.Line(end-1) == "" {
+		end--
+	}
+	start := max(end-rows, 0)
+
+	lines := make([]string, 0, end-start)
+	for row := start; row < end; row++ {
+		lines = append(lines, renderRow(scr, row, width))
+	}
+	return lines
+}
+
+// renderRow 將一列中屬性相同的連續儲存格合併後以對應的 lipgloss 樣式渲染。
+func renderRow(scr *tmux.Screen, row, width int) string {
+	w, _ := scr.Size()
+	limit := min(w, width)
+	// 去除結尾的預設屬性空白，避免渲染整列寬度的空白
+	for limit > 0 && scr.Cell(row, limit-1) == tmux.BlankCell {
+		limit--
+	}
+
+	var b, run strings.Builder
+	var attr tmux.Attr
+	flush := func() {
+		if run.Len() > 0 {
+			b.WriteString(cellStyle(attr).Render(run.String()))
+			run.Reset()
+		}
+	}
+	for col := 0; col < limit; col++ {
+		c := scr.Cell(row, col)
+		if c.Rune == 0 {
+			continue // 寬字元的第二格
+		}
+		if col == 0 || c.Attr != attr {
+			flush()
+			attr = c.Attr
+		}
+		r := c.Rune
+		if c.Attr.Hidden {
+			r = ' '
+		}
+		// 寬字元的第二格被截斷時以空白取代，避免超出寬度
+		if col == limit-1 && scr.Cell(row, col+1).Rune == 0 {
+			r = ' '
+		}
+		run.WriteRune(r)
+	}
+	flush()
+	return b.String()
+}
+
+// cellStyle 將畫面屬性轉換為 lipgloss 樣式。
+func cellStyle(a tmux.Attr) lipgloss.Style {
+	s := lipgloss.NewStyle().
+		Bold(a.Bold).
+		Faint(a.Dim).
+		Italic(a.Italic).
+		Underline(a.Underline).
+		Blink(a.Blink).
+		Reverse(a.Reverse).
+		Strikethrough(a.Strike)
+	if a.Fg != tmux.ColorDefault {
+		s = s.Foreground(lipglossColor(a.Fg))
+	}
+	if a.Bg != tmux.ColorDefault {
+		s = s.Background(lipglossColor(a.Bg))
+	}
+	return s
+}
+
+// lipglossColor 將畫面顏色轉換為 lipgloss 顏色。
+func lipglossColor(c tmux.Color) lipgloss.Color {
+	if c.IsRGB() {
+		r, g, b := c.RGB()
+		return lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", r, g, b))
+	}
+	return lipgloss.Color(strconv.Itoa(int(c)))
+}
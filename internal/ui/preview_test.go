@@ -0,0 +1,84 @@
+package ui_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wake/tmux-session-menu/internal/config"
+	"github.com/wake/tmux-session-menu/internal/tmux"
+	"github.com/wake/tmux-session-menu/internal/ui"
+)
+
+// previewSessions 回傳兩個各有一個 active pane 的 session。
+func previewSessions() []tmux.Session {
+	return []tmux.Session{
+		{Name: "alpha", Windows: []tmux.Window{{Panes: []tmux.Pane{{ID: "%1", Active: true, Width: 40}}}}},
+		{Name: "beta", Windows: []tmux.Window{{Panes: []tmux.Pane{{ID: "%2", Active: true, Width: 40}}}}},
+	}
+}
+
+// runCmd 執行 cmd 並將結果送回 model。
+func runCmd(t *testing.T, m ui.Model, cmd tea.Cmd) ui.Model {
+	t.Helper()
+	require.NotNil(t, cmd)
+	updated, _ := m.Update(cmd())
+	return updated.(ui.Model)
+}
+
+func TestModel_Preview_ShowsCapture(t *testing.T) {
+	exec := &fakeExecutor{outputs: map[string]string{
+		"capture-pane -t %1 -p -e -S -150": "\033[31mbuild failed\033[0m\n\033[1m❯\033[0m retry",
+	}}
+	m := ui.NewModel(ui.Deps{TmuxMgr: tmux.NewManager(exec), Cfg: config.Default()})
+
+	updated, cmd := m.Update(ui.SessionsMsg{Sessions: previewSessions()})
+	m = updated.(ui.Model)
+	assert.Contains(t, m.View(), "loading…")
+
+	m = runCmd(t, m, cmd)
+	view := m.View()
+	assert.Contains(t, view, "build failed")
+	assert.Contains(t, view, "❯ retry")
+}
+
+func TestModel_Preview_CachedOnCursorMove(t *testing.T) {
+	exec := &fakeExecutor{outputs: map[string]string{}}
+	m := ui.NewModel(ui.Deps{TmuxMgr: tmux.NewManager(exec), Cfg: config.Default()})
+	updated, cmd := m.Update(ui.SessionsMsg{Sessions: previewSessions()})
+	m = runCmd(t, updated.(ui.Model), cmd)
+
+	// beta 尚無快取，背景擷取
+	m, cmd = applyKey(m, "j")
+	m = runCmd(t, m, cmd)
+	// 回到 alpha 時沿用快取，不重新擷取
+	m, cmd = applyKey(m, "k")
+	assert.Nil(t, cmd)
+
+	assert.Equal(t, []string{
+		"capture-pane -t %1 -p -e -S -150",
+		"capture-pane -t %2 -p -e -S -150",
+	}, exec.calls)
+}
+
+func TestModel_Preview_FillsRemainingHeight(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 40; i++ {
+		lines = append(lines, fmt.Sprintf("output line %d", i))
+	}
+	exec := &fakeExecutor{outputs: map[string]string{
+		"capture-pane -t %1 -p -e -S -150": strings.Join(lines, "\n"),
+	}}
+	m := ui.NewModel(ui.Deps{TmuxMgr: tmux.NewManager(exec), Cfg: config.Default()})
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 60, Height: 20})
+	updated, cmd := updated.(ui.Model).Update(ui.SessionsMsg{Sessions: previewSessions()})
+	m = runCmd(t, updated.(ui.Model), cmd)
+
+	view := m.View()
+	assert.Equal(t, 20, strings.Count(view, "\n")+1)
+	assert.Contains(t, view, "output line 40")
+	assert.NotContains(t, view, "output line 1\n")
+}
@@ -10,7 +10,13 @@ var (
 	statusWaitingStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#e0af68"))
 	statusIdleStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#787fa0"))
 	statusErrorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#f7768e"))
+	matchStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ff9e64")).Bold(true).Underline(true)
+	agentBadgeStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#bb9af7"))
 	previewBorderStyle = lipgloss.NewStyle().
 		Border(lipgloss.NormalBorder(), true, false, false, false).
 		BorderForeground(lipgloss.Color("#414868"))
+	previewSideStyle = lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(lipgloss.Color("#414868")).
+		PaddingLeft(1)
 )